@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pplmx/h2h/internal"
+	"github.com/spf13/cobra"
+)
+
+// encryptionKeyEnv is read when --encryption-key is not given, to keep the key out of shell history
+const encryptionKeyEnv = "H2H_ENCRYPTION_KEY"
+
+func newDecryptCmd() *cobra.Command {
+	decryptCmd := &cobra.Command{
+		Use:   "decrypt",
+		Short: "Decrypt front matter fields encrypted during conversion",
+		Long: `decrypt reverses --encrypt-field: it decrypts the listed front matter fields of
+every Markdown file in the source directory and writes the result to the destination directory.`,
+		RunE: runDecrypt,
+	}
+
+	flags := decryptCmd.Flags()
+	flags.StringVar(&srcDir, "src", "", "source directory containing Markdown files to decrypt (required)")
+	flags.StringVar(&dstDir, "dst", "", "destination directory to write decrypted Markdown files (required)")
+	flags.StringVar(&config.TargetFormat, "format", config.TargetFormat, "FrontMatter format of the encrypted files (yaml, toml or json)")
+	flags.StringVar(&config.FileExtension, "file-extension", config.FileExtension, "file extension for Markdown files")
+	flags.StringSliceVar(&config.FrontMatterEncryptFields, "encrypt-field", config.FrontMatterEncryptFields, "front matter field to decrypt (repeatable)")
+	flags.StringVar(&config.EncryptionKey, "encryption-key", "", "hex-encoded 32-byte AES key (default $"+encryptionKeyEnv+")")
+
+	cobra.CheckErr(decryptCmd.MarkFlagRequired("src"))
+	cobra.CheckErr(decryptCmd.MarkFlagRequired("dst"))
+	cobra.CheckErr(decryptCmd.MarkFlagRequired("encrypt-field"))
+	return decryptCmd
+}
+
+func runDecrypt(cmd *cobra.Command, args []string) error {
+	config.SourceFormat = config.TargetFormat
+	if config.EncryptionKey == "" {
+		config.EncryptionKey = os.Getenv(encryptionKeyEnv)
+	}
+
+	srcDirAbs, err := filepath.Abs(srcDir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path for source directory: %w", err)
+	}
+
+	dstDirAbs, err := filepath.Abs(dstDir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path for destination directory: %w", err)
+	}
+
+	if err := internal.DecryptPosts(srcDirAbs, dstDirAbs, config); err != nil {
+		return fmt.Errorf("decryption failed: %w", err)
+	}
+
+	fmt.Println("Decryption completed successfully")
+	return nil
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pplmx/h2h/internal"
+	"github.com/spf13/cobra"
+)
+
+var keyMapFormat string
+
+func newDumpKeyMapCmd() *cobra.Command {
+	dumpKeyMapCmd := &cobra.Command{
+		Use:   "dump-key-map",
+		Short: "Print the built-in front matter key map of a conversion direction",
+		Long: `dump-key-map prints the map from source to target front matter keys that a
+conversion in the given direction applies, for inspection or as a starting point
+for a custom key map. Dots in target keys nest them in maps.`,
+		Args: cobra.NoArgs,
+		RunE: runDumpKeyMap,
+	}
+
+	flags := dumpKeyMapCmd.Flags()
+	flags.StringVar(&config.ConversionDirection, "direction", config.ConversionDirection, "conversion direction (hexo2hugo, hugo2hexo or passthrough)")
+	flags.StringVar(&keyMapFormat, "format", "yaml", "output format (yaml or toml)")
+	flags.BoolVar(&nestParams, "no-flatten-params", false, "show keys such as author nested under params")
+	return dumpKeyMapCmd
+}
+
+func runDumpKeyMap(cmd *cobra.Command, args []string) error {
+	if nestParams {
+		config.FlattenParams = false
+	}
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	fmc := internal.NewFrontMatterConverter(config)
+	if err := internal.ExportKeyMap(fmc, keyMapFormat, os.Stdout); err != nil {
+		return fmt.Errorf("writing key map: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+//go:build h2h_pprof
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+var (
+	cpuProfile string
+	memProfile string
+)
+
+func initProfileFlags() {
+	flags := rootCmd.Flags()
+	flags.StringVar(&cpuProfile, "cpu-profile", "", "write a pprof CPU profile of the conversion to this file")
+	flags.StringVar(&memProfile, "mem-profile", "", "write a pprof heap profile to this file after conversion")
+}
+
+// startProfiling starts CPU profiling if requested and returns a function
+// that stops it and writes the heap profile
+func startProfiling() (func() error, error) {
+	var cpuFile *os.File
+	if cpuProfile != "" {
+		f, err := os.Create(cpuProfile)
+		if err != nil {
+			return nil, fmt.Errorf("creating CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("starting CPU profile: %w", err)
+		}
+		cpuFile = f
+	}
+
+	return func() error {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				return fmt.Errorf("closing CPU profile: %w", err)
+			}
+		}
+
+		if memProfile != "" {
+			f, err := os.Create(memProfile)
+			if err != nil {
+				return fmt.Errorf("creating heap profile: %w", err)
+			}
+			defer f.Close()
+
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				return fmt.Errorf("writing heap profile: %w", err)
+			}
+		}
+		return nil
+	}, nil
+}
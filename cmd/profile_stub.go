@@ -0,0 +1,10 @@
+//go:build !h2h_pprof
+
+package cmd
+
+// initProfileFlags is a no-op unless built with the h2h_pprof tag
+func initProfileFlags() {}
+
+func startProfiling() (func() error, error) {
+	return func() error { return nil }, nil
+}
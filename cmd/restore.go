@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/pplmx/h2h/internal"
+	"github.com/spf13/cobra"
+)
+
+// backupSuffix is appended to the names of files backed up by --backup
+const backupSuffix = ".bak"
+
+var (
+	restoreSuffix  string
+	restoreBackups bool
+)
+
+func newRestoreCmd() *cobra.Command {
+	restoreCmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore the files backed up by --backup",
+		Long: `restore undoes a conversion run with --backup: with --restore-backups it renames
+every backup file in the destination directory back over the converted file it was
+made from. Without --restore-backups it only lists the backup files it would restore.`,
+		RunE: runRestore,
+	}
+
+	flags := restoreCmd.Flags()
+	flags.StringVar(&dstDir, "dst", "", "directory the conversion wrote to (required)")
+	flags.StringVar(&restoreSuffix, "suffix", backupSuffix, "suffix of the backup files")
+	flags.BoolVar(&restoreBackups, "restore-backups", false, "rename the backup files back over the converted files instead of listing them")
+
+	cobra.CheckErr(restoreCmd.MarkFlagRequired("dst"))
+	return restoreCmd
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	dstDirAbs, err := filepath.Abs(dstDir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path for destination directory: %w", err)
+	}
+
+	if !restoreBackups {
+		backups, err := internal.FindBackups(dstDirAbs, restoreSuffix)
+		if err != nil {
+			return fmt.Errorf("finding backups: %w", err)
+		}
+		for _, path := range backups {
+			fmt.Println(path)
+		}
+		fmt.Printf("Found %d backup files, pass --restore-backups to restore them\n", len(backups))
+		return nil
+	}
+
+	restored, err := internal.RestoreBackups(dstDirAbs, restoreSuffix)
+	if err != nil {
+		return fmt.Errorf("restoring backups: %w", err)
+	}
+
+	fmt.Printf("Restored %d files\n", restored)
+	return nil
+}
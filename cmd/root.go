@@ -1,21 +1,47 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/pplmx/h2h/internal"
 	"github.com/spf13/cobra"
 )
 
 var (
-	srcDir  string
-	dstDir  string
-	config  *internal.Config
-	rootCmd *cobra.Command
+	srcDir       string
+	dstDir       string
+	noSkipHidden bool
+	noAtomic     bool
+	noModTime    bool
+	nestParams   bool
+	noUTF8Check  bool
+	injectKeys   map[string]string
+	backup       bool
+	logFile      string
+	logLevel     string
+	logFormat    string
+	verbose      bool
+	noLogFile    bool
+	inPlace      bool
+	watch        bool
+	config       *internal.Config
+	rootCmd      *cobra.Command
+	logger       = slog.New(slog.NewTextHandler(os.Stderr, nil))
 )
 
+// digestKeyEnv is read when --digest-key is not given, to keep the key out of shell history
+const digestKeyEnv = "H2H_DIGEST_KEY"
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -27,6 +53,11 @@ func init() {
 	config = internal.NewDefaultConfig()
 	initRootCmd()
 	initFlags()
+	initProfileFlags()
+	rootCmd.AddCommand(newSchemaCmd())
+	rootCmd.AddCommand(newDecryptCmd())
+	rootCmd.AddCommand(newRestoreCmd())
+	rootCmd.AddCommand(newDumpKeyMapCmd())
 }
 
 func initRootCmd() {
@@ -45,19 +76,226 @@ By default, it converts from Hexo to Hugo format using YAML.`,
 
 func initFlags() {
 	flags := rootCmd.Flags()
-	flags.StringVar(&srcDir, "src", "", "source directory containing Markdown files to convert (required)")
-	flags.StringVar(&dstDir, "dst", "", "destination directory to write converted Markdown files (required)")
-	flags.StringVar(&config.SourceFormat, "source-format", config.SourceFormat, "source FrontMatter format (yaml or toml)")
-	flags.StringVar(&config.TargetFormat, "target-format", config.TargetFormat, "target FrontMatter format (yaml or toml)")
+	flags.StringVar(&srcDir, "src", "", "source directory containing Markdown files to convert, or a single Markdown file (required)")
+	flags.StringVar(&dstDir, "dst", "", "destination directory to write converted Markdown files (required unless --in-place)")
+	flags.BoolVar(&inPlace, "in-place", false, "convert the files in the source directory in place")
+	flags.BoolVar(&watch, "watch", false, "keep running and convert files as they are created or modified")
+	flags.DurationVar(&config.WatchDebounce, "watch-debounce", config.WatchDebounce, "how long --watch waits for further changes to a file before converting it")
+	flags.BoolVar(&config.WatchDelete, "watch-delete", config.WatchDelete, "remove destination files when --watch sees their source deleted")
+	flags.StringVar(&config.SourceFormat, "source-format", config.SourceFormat, "source FrontMatter format (yaml, toml, json, or auto to detect it per file)")
+	flags.StringVar(&config.TargetFormat, "target-format", config.TargetFormat, "target FrontMatter format (yaml, toml, json, or frontmatter-only-json to write it to a .json file next to the body)")
+	flags.BoolVar(&config.ForceTargetFormat, "force-target-format", config.ForceTargetFormat, "always write --target-format, even when --source-format is auto")
+	flags.StringVar(&config.InputDelimiter, "input-delimiter", config.InputDelimiter, "line enclosing source front matter, e.g. ;;; (default: detect --- or +++)")
+	flags.StringVar(&config.OutputDelimiter, "output-delimiter", config.OutputDelimiter, "front matter delimiter to write (--- or +++); defaults to the source delimiter")
+	flags.StringVar(&config.CustomFrontMatterSeparator, "separator", config.CustomFrontMatterSeparator, "custom front matter delimiter, e.g. ===, used for both reading and writing")
+	flags.IntVar(&config.MaxLineLength, "max-line-length", config.MaxLineLength, "warn about front matter lines longer than this (0 disables)")
+	flags.IntVar(&config.JSONIndent, "json-indent", config.JSONIndent, "spaces to indent JSON front matter by (0 for compact output)")
+	flags.IntVar(&config.YAMLIndent, "yaml-indent", config.YAMLIndent, "spaces to indent nested YAML front matter by (2 or 4, 0 for the default)")
+	flags.IntVar(&config.FrontMatterMarshalPrecision, "float-precision", config.FrontMatterMarshalPrecision, "round float front matter values to this many decimal places (0 disables)")
+	flags.IntVar(&config.FrontMatterFieldWidth, "field-width", config.FrontMatterFieldWidth, "pad top-level YAML keys to this many characters so values line up (0 disables)")
+	flags.IntVar(&config.TOMLIndent, "toml-indent", config.TOMLIndent, "spaces to indent nested TOML tables by (advisory, 0 for none)")
 	flags.StringVar(&config.FileExtension, "file-extension", config.FileExtension, "file extension for Markdown files")
+	flags.IntVar(&config.OutputBufferSize, "output-buffer-size", config.OutputBufferSize, "write buffer size in bytes for destination files (0 uses the default)")
 	flags.IntVar(&config.MaxConcurrency, "max-concurrency", config.MaxConcurrency, "maximum number of concurrent file conversions")
-	flags.StringVar(&config.ConversionDirection, "direction", config.ConversionDirection, "conversion direction (hexo2hugo or hugo2hexo)")
+	flags.BoolVar(&config.ReverseOrderProcessing, "reverse-order", config.ReverseOrderProcessing, "convert files one at a time in reverse order of their paths")
+	flags.BoolVar(&config.AutoScaleWorkers, "auto-scale-workers", config.AutoScaleWorkers, "reduce concurrent conversions while memory usage is above --memory-limit-mb")
+	flags.IntVar(&config.MemoryLimitMB, "memory-limit-mb", config.MemoryLimitMB, "memory usage in MiB above which --auto-scale-workers reduces concurrency")
+	flags.StringSliceVar(&config.IncludeGlobs, "include", config.IncludeGlobs, "only convert files matching these globs relative to --src (comma-separated or repeatable)")
+	flags.StringSliceVar(&config.ExcludeGlobs, "exclude", config.ExcludeGlobs, "skip files matching these globs relative to --src (comma-separated or repeatable)")
+	flags.IntVar(&config.FileRetries, "file-retries", config.FileRetries, "retry failed file reads and writes this many times with exponential backoff")
+	flags.DurationVar(&config.RetryDelay, "retry-delay", config.RetryDelay, "delay before the first --file-retries retry, doubled for each further one")
+	flags.DurationVar(&config.MaxRetryBackoff, "max-retry-backoff", config.MaxRetryBackoff, "longest delay between --file-retries retries")
+	flags.DurationVar(&config.FileTimeout, "file-timeout", config.FileTimeout, "longest time one file read or write may take including retries (0 for no limit)")
+	flags.StringVar(&config.TemporaryDirectory, "temp-dir", config.TemporaryDirectory, "directory for the temporary files of atomic writes, on the same filesystem as --dst (default: next to each destination file)")
+	flags.BoolVar(&noAtomic, "no-atomic-writes", false, "write destination files directly instead of through a temporary file (ignored with --in-place)")
+	flags.BoolVar(&backup, "backup", false, "copy destination files to <file>.bak before overwriting them (undo with the restore command)")
+	flags.StringVar(&config.ManifestFile, "manifest", config.ManifestFile, "write a JSON manifest of the files written for every source file")
+	flags.StringVar(&config.ChecksumFile, "checksum-file", config.ChecksumFile, "write the SHA-256 of every destination file to this file, verifiable with sha256sum --check")
+	flags.BoolVar(&config.WriteAtomicManifest, "manifest-on-success", config.WriteAtomicManifest, "write the --manifest only when every file converts successfully")
+	flags.BoolVar(&config.ComputeChecksum, "checksum", config.ComputeChecksum, "add the SHA-256 of every source file to the --manifest entries")
+	flags.BoolVar(&config.NormalizeTags, "normalize-tags", config.NormalizeTags, "trim, re-case and deduplicate categories, tags and keywords")
+	flags.StringVar(&config.TagCase, "tag-case", "lower", "casing --normalize-tags applies (lower, upper or title)")
+	flags.StringVar(&config.RawTagsField, "raw-tags-field", config.RawTagsField, "copy the tags, as they were before any transformation, to this front matter field")
+	flags.StringToStringVar(&config.RawListFields, "raw-list-field", config.RawListFields, "copy a list field, as it was before any transformation, to another field, e.g. categories=original_categories (repeatable)")
+	flags.BoolVar(&config.InjectH2HVersion, "inject-version", config.InjectH2HVersion, "add _h2h_version and _h2h_direction fields to the converted front matter")
+	flags.BoolVar(&config.SkipExisting, "skip-existing", config.SkipExisting, "skip source files whose destination file already exists")
+	flags.StringToStringVar(&config.ImageKeys, "image-key", config.ImageKeys, "Hexo image key to Hugo key mapping, e.g. banner=images (replaces the defaults; repeatable)")
+	flags.BoolVar(&config.RewriteImagePaths, "rewrite-image-paths", config.RewriteImagePaths, "rewrite site-absolute image paths such as /img/foo.png to page bundle paths such as foo.png")
+	flags.BoolVar(&noUTF8Check, "no-enforce-utf8", false, "convert source files that are not valid UTF-8 instead of failing them")
+	flags.BoolVar(&nestParams, "no-flatten-params", false, "nest keys such as author under params in Hugo front matter")
+	flags.BoolVar(&noModTime, "no-preserve-mtime", false, "give destination files the current time instead of the modification time of their source")
+	flags.StringVar(&logFile, "log-file", "", "file to append logs to, or - for stderr (default stderr)")
+	flags.BoolVar(&noLogFile, "no-log-file", false, "log to stderr instead of a file")
+	flags.IntVar(&config.LogMaxSizeMB, "log-max-size-mb", config.LogMaxSizeMB, "rotate the --log-file once it exceeds this many megabytes (0 disables rotation)")
+	flags.IntVar(&config.LogMaxBackups, "log-max-backups", config.LogMaxBackups, "number of rotated log files to keep as <log-file>.1, .2, ...")
+	flags.BoolVar(&config.ShowProgress, "progress", config.ShowProgress, "show a progress bar on stderr while converting (only on a terminal)")
+	flags.BoolVarP(&verbose, "verbose", "v", false, "print every file to stderr as its conversion starts")
+	flags.StringVar(&logLevel, "log-level", "info", "minimum level of logged messages (debug, info, warn or error)")
+	flags.StringVar(&logFormat, "log-format", "text", "format of logged messages (text or json)")
+	flags.BoolVar(&noSkipHidden, "no-skip-hidden", false, "also convert dot-prefixed files and files in dot-prefixed directories")
+	flags.StringVar(&config.ConversionDirection, "direction", config.ConversionDirection, "conversion direction (hexo2hugo, hugo2hexo or passthrough)")
+	flags.BoolVar(&config.KeyWhitelistMode, "whitelist-keys", config.KeyWhitelistMode, "drop front matter keys missing from the key map")
+	flags.BoolVar(&config.GenerateOpenGraphFields, "open-graph", config.GenerateOpenGraphFields, "add missing og_title, og_description and og_image fields from title, description and featured_image")
+	flags.StringToStringVar(&config.OGFieldMap, "og-field", config.OGFieldMap, "Open Graph key to source key mapping for --open-graph, e.g. og_image=images (replaces the defaults; repeatable)")
+	flags.BoolVar(&config.GenerateSchemaOrgFields, "schema-org", config.GenerateSchemaOrgFields, "add a schema_org map with Schema.org BlogPosting fields for JSON-LD")
+	flags.StringToStringVar(&injectKeys, "inject", nil, "key=value pair to set in every converted front matter, e.g. migrated=2024-01-01 (repeatable)")
+	flags.StringToStringVar(&config.FrontMatterTypeHints, "type-hint", config.FrontMatterTypeHints, "key=type pair converting a front matter value to string, int, float, bool or []string (repeatable)")
+	flags.BoolVar(&config.KeywordsFromTags, "keywords-from-tags", config.KeywordsFromTags, "copy the tags to keywords when the front matter has none")
+	flags.BoolVar(&config.MergeKeywords, "merge-keywords", config.MergeKeywords, "with --keywords-from-tags, also append tags missing from existing keywords")
+	flags.StringSliceVar(&config.DropKeys, "drop-key", config.DropKeys, "converted front matter keys to remove, e.g. abbrlink (comma-separated or repeatable)")
+	flags.BoolVar(&config.FailOnUnmappedKeys, "fail-on-unmapped", config.FailOnUnmappedKeys, "fail files with front matter keys missing from the key map")
+	flags.StringSliceVar(&config.IgnoreFields, "ignore-field", config.IgnoreFields, "front matter keys --fail-on-unmapped accepts (comma-separated or repeatable)")
+	flags.StringVar(&config.NewKeyForUnmapped, "unmapped-key", config.NewKeyForUnmapped, "nest front matter keys missing from the key map under this key (e.g. params)")
+	flags.IntVar(&config.TruncateDescription, "truncate-description", config.TruncateDescription, "truncate descriptions longer than this many characters (0 disables)")
+	flags.BoolVar(&config.ConvertCategoriesToSections, "categories-to-sections", config.ConvertCategoriesToSections, "write each post into a section directory named after its first category")
+	flags.BoolVar(&config.GenerateReadingTime, "reading-time", config.GenerateReadingTime, "inject a reading_time field (in minutes) computed from the post body")
+	flags.IntVar(&config.ReadingSpeedWPM, "reading-speed", config.ReadingSpeedWPM, "reading speed in words per minute used for reading_time")
+	flags.BoolVar(&config.AutoDescription, "auto-description", config.AutoDescription, "set a missing description from the first paragraph of the body")
+	flags.IntVar(&config.DescriptionMaxLen, "description-max-len", config.DescriptionMaxLen, "maximum length in characters of descriptions set by --auto-description")
+	flags.BoolVar(&config.GenerateWordCount, "word-count", config.GenerateWordCount, "inject a word_count field computed from the post body")
+	flags.BoolVar(&config.SlugFromTitle, "slug-from-title", config.SlugFromTitle, "generate a missing slug from the post title")
+	flags.StringVar(&config.NewlineNormalization, "newlines", config.NewlineNormalization, "line endings for front matter string values (lf, crlf or none)")
+	flags.BoolVar(&config.EmptyStringAsNull, "empty-as-null", config.EmptyStringAsNull, "write empty string values as null (omitted in TOML)")
+	flags.BoolVar(&config.NormalizeDates, "normalize-dates", config.NormalizeDates, "rewrite --date-key values in --date-layout")
+	flags.StringSliceVar(&config.DateKeys, "date-key", config.DateKeys, "front matter field holding a date for --normalize-dates (repeatable)")
+	flags.StringVar(&config.DateLayout, "date-layout", config.DateLayout, "Go time layout for --normalize-dates (default RFC 3339 for hexo2hugo, 2006-01-02 for hugo2hexo)")
+	flags.StringSliceVar(&config.RequireFields, "require-field", config.RequireFields, "front matter field every converted post must have (repeatable)")
+	flags.StringVar(&config.FrontMatterNullChar, "null-char", config.FrontMatterNullChar, "value written for missing --require-field fields instead of failing")
+	flags.BoolVar(&config.StripMarkdownFormatting, "strip-markdown", config.StripMarkdownFormatting, "convert Markdown in --markdown-field values to plain text")
+	flags.StringSliceVar(&config.MarkdownFields, "markdown-field", config.MarkdownFields, "front matter field holding Markdown for --strip-markdown (repeatable)")
+	flags.BoolVar(&config.SanitizeSlug, "sanitize-slug", config.SanitizeSlug, "normalise slug values to a URL-safe form")
+	flags.StringVar(&config.FrontMatterPatchFile, "patch-file", config.FrontMatterPatchFile, "JSON Patch (RFC 6902) file applied to every converted front matter")
+	flags.StringVar(&config.OPAPolicyFile, "opa-policy", config.OPAPolicyFile, "Rego policy file; files for which data.h2h.deny is non-empty are rejected")
+	flags.BoolVar(&config.IgnoreErrors, "ignore-errors", config.IgnoreErrors, "copy files that fail to convert unchanged instead of failing")
+	flags.BoolVar(&config.ForkOnError, "fork-on-error", config.ForkOnError, "with --ignore-errors, write a best-effort conversion plus a copy of the source as <file>.original")
+	flags.BoolVar(&config.AnnotateErrors, "annotate-errors", config.AnnotateErrors, "prepend the conversion error as an HTML comment to files copied by --ignore-errors")
+	flags.StringToStringVar(&config.FrontMatterStyle, "yaml-style", config.FrontMatterStyle, "YAML style per field, e.g. tags=flow,categories=block")
+	flags.StringVar(&config.PostSortKey, "sort-key", config.PostSortKey, "prefix converted file names with their position when sorted by this front matter field")
+	flags.BoolVar(&config.ConvertCodeblockLanguages, "convert-code-languages", config.ConvertCodeblockLanguages, "rename code block languages such as js and py to the names Hugo expects")
+	flags.StringToStringVar(&config.CodeblockLanguageMap, "code-language", config.CodeblockLanguageMap, "code block language renames for --convert-code-languages, replacing the defaults (e.g. js=javascript)")
+	flags.BoolVar(&config.ConvertRelativeLinks, "convert-relative-links", config.ConvertRelativeLinks, "rewrite relative links in the body to point to the same files from the destination directory")
+	flags.StringVar(&config.LineBreakStrategy, "line-breaks", config.LineBreakStrategy, "line breaks within body paragraphs (softbreak joins the lines, hardbreak keeps them as hard breaks, none)")
+	flags.StringVar(&config.ContentFooter, "content-footer", config.ContentFooter, "Go template appended to every post body, e.g. '{{< related-posts >}}'; front matter fields are available as {{ .title }}")
+	flags.BoolVar(&config.ConvertSelfClosingHTMLTags, "fix-self-closing-tags", config.ConvertSelfClosingHTMLTags, "rewrite self-closing <br/>, <hr/> and <img/> tags in the body")
+	flags.BoolVar(&config.StripHTMLCommentsFromBody, "strip-html-comments", config.StripHTMLCommentsFromBody, "remove HTML comments from post bodies, keeping <!--more-->")
+	flags.BoolVar(&config.KeepOriginalFrontMatter, "keep-original", config.KeepOriginalFrontMatter, "append the original front matter as a comment block for review")
+	flags.StringVar(&config.FrontMatterCommentChar, "comment-char", config.FrontMatterCommentChar, "prefix of the comment lines --keep-original adds to the front matter")
+	flags.BoolVar(&config.SplitLongPosts, "split-long-posts", config.SplitLongPosts, "split posts longer than --split-at-lines at headings into multiple parts")
+	flags.IntVar(&config.SplitAtLines, "split-at-lines", config.SplitAtLines, "approximate number of body lines per part for --split-long-posts")
+	flags.BoolVar(&config.TitleFromH1, "title-from-h1", config.TitleFromH1, "use the first H1 heading as the title when the front matter has none")
+	flags.BoolVar(&config.RemoveH1FromBody, "remove-h1", config.RemoveH1FromBody, "remove the H1 heading used by --title-from-h1 from the body")
+	flags.BoolVar(&config.AlwaysQuoteStrings, "quote-strings", config.AlwaysQuoteStrings, "double-quote every string value in YAML output")
+	flags.StringSliceVar(&config.FrontMatterEncryptFields, "encrypt-field", config.FrontMatterEncryptFields, "front matter field to encrypt with AES-256-GCM (repeatable)")
+	flags.StringVar(&config.EncryptionKey, "encryption-key", "", "hex-encoded 32-byte AES key for --encrypt-field (default $"+encryptionKeyEnv+")")
+	flags.BoolVar(&config.DigestFrontMatter, "digest", config.DigestFrontMatter, "add a _digest field with an HMAC-SHA256 of the front matter")
+	flags.StringVar(&config.DigestKey, "digest-key", "", "hex-encoded HMAC key for --digest (default $"+digestKeyEnv+")")
+	flags.BoolVar(&config.EnableCaching, "cache", config.EnableCaching, "cache converted front matter between runs")
+	flags.StringVar(&config.CacheDir, "cache-dir", config.CacheDir, "directory for --cache (default <user cache dir>/h2h)")
+	flags.BoolVar(&config.GenerateTaxonomyFiles, "taxonomy-pages", config.GenerateTaxonomyFiles, "create a _index.md page for every tag and category that has none")
+	flags.BoolVar(&config.ExportFrontMatterOnly, "front-matter-only", config.ExportFrontMatterOnly, "write only the converted front matter, without the post body")
+	flags.StringVar(&config.OpenMetricsFile, "metrics-file", config.OpenMetricsFile, "write conversion metrics in OpenMetrics text format to this file")
+	flags.BoolVar(&config.DryRun, "dry-run", config.DryRun, "report the files that would be written without writing anything")
+	flags.BoolVar(&config.OmitFrontMatterIfUnchanged, "omit-unchanged", config.OmitFrontMatterIfUnchanged, "skip writing files whose converted content is identical to the source")
 
 	cobra.CheckErr(rootCmd.MarkFlagRequired("src"))
-	cobra.CheckErr(rootCmd.MarkFlagRequired("dst"))
+	rootCmd.MarkFlagsOneRequired("dst", "in-place")
+	rootCmd.MarkFlagsMutuallyExclusive("dst", "in-place")
+	rootCmd.MarkFlagsMutuallyExclusive("watch", "in-place")
+	rootCmd.MarkFlagsMutuallyExclusive("watch", "dry-run")
+	rootCmd.MarkFlagsMutuallyExclusive("skip-existing", "in-place")
+	rootCmd.MarkFlagsMutuallyExclusive("skip-existing", "watch")
+	rootCmd.MarkFlagsMutuallyExclusive("log-file", "no-log-file")
+}
+
+// initLogger points logger, and the logger of the conversion, at the file
+// path, or at stderr when path is empty or "-", logging messages of level
+// and above in format. The returned function closes the log file.
+func initLogger(path, level, format string) (func() error, error) {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid --log-level %q: must be debug, info, warn or error", level)
+	}
+	opts := &slog.HandlerOptions{Level: minLevel}
+
+	var newHandler func(io.Writer, *slog.HandlerOptions) slog.Handler
+	switch format {
+	case "text":
+		newHandler = func(w io.Writer, opts *slog.HandlerOptions) slog.Handler { return slog.NewTextHandler(w, opts) }
+	case "json":
+		newHandler = func(w io.Writer, opts *slog.HandlerOptions) slog.Handler { return slog.NewJSONHandler(w, opts) }
+	default:
+		return nil, fmt.Errorf("invalid --log-format %q: must be text or json", format)
+	}
+
+	if path == "" || path == "-" {
+		logger = slog.New(newHandler(os.Stderr, opts))
+		config.Logger = logger
+		return func() error { return nil }, nil
+	}
+
+	f, err := internal.OpenRotatingFile(path, config.LogMaxSizeMB, config.LogMaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	logger = slog.New(newHandler(f, opts))
+	config.Logger = logger
+	return f.Close, nil
 }
 
 func runConversion(cmd *cobra.Command, args []string) error {
+	if noLogFile {
+		logFile = ""
+	}
+	closeLog, err := initLogger(logFile, logLevel, logFormat)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	if noSkipHidden {
+		config.SkipHiddenFiles = false
+	}
+	if noAtomic {
+		config.AtomicWrites = false
+	}
+	if noModTime {
+		config.PreserveModTime = false
+	}
+	if nestParams {
+		config.FlattenParams = false
+	}
+	if noUTF8Check {
+		config.EnforceUTF8 = false
+	}
+	for key, value := range injectKeys {
+		if config.InjectKeys == nil {
+			config.InjectKeys = make(map[string]interface{}, len(injectKeys))
+		}
+		config.InjectKeys[key] = internal.ParseFrontMatterValue(value)
+	}
+	if backup {
+		config.BackupSuffix = backupSuffix
+	}
+	if inPlace {
+		dstDir = srcDir
+	}
+	if verbose {
+		config.OnFileStart = printProgress
+	}
+	if config.OpenMetricsFile != "" {
+		config.EnableOpenMetrics = true
+	}
+	if config.EncryptionKey == "" {
+		config.EncryptionKey = os.Getenv(encryptionKeyEnv)
+	}
+	if config.DigestKey == "" {
+		config.DigestKey = os.Getenv(digestKeyEnv)
+	}
+
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
 	fmt.Printf("Starting conversion from [%s] to [%s] format, direction: %s, output will be written to [%s]\n",
 		config.SourceFormat, config.TargetFormat, config.ConversionDirection, dstDir)
 
@@ -71,10 +309,89 @@ func runConversion(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get absolute path for destination directory: %w", err)
 	}
 
-	if err := internal.ConvertPosts(srcDirAbs, dstDirAbs, config); err != nil {
-		return fmt.Errorf("conversion failed: %w", err)
+	convert := internal.ConvertPosts
+	singleFile := false
+	if info, err := os.Stat(srcDirAbs); err == nil && !info.IsDir() {
+		convert, singleFile = convertSingleFile, true
+	}
+
+	stopProfiling, err := startProfiling()
+	if err != nil {
+		return err
+	}
+
+	summary, convErr := convert(srcDirAbs, dstDirAbs, config)
+	if err := stopProfiling(); err != nil {
+		return err
+	}
+	var errList *internal.ConversionErrorList
+	if errors.As(convErr, &errList) {
+		for _, e := range errList.Errors() {
+			logger.Error("converting file", "file", e.SourceFile, "error", e.Err)
+		}
+	}
+	logger.Info("conversion finished", "converted", summary.SuccessCount, "skipped", summary.SkippedCount,
+		"failed", len(summary.Errors), "duration", summary.Duration, "dry_run", config.DryRun)
+	if config.DryRun {
+		printDryRun(summary)
+	}
+	if convErr != nil {
+		return fmt.Errorf("conversion failed: %w", convErr)
+	}
+	if config.DryRun {
+		fmt.Println("Dry run completed, no files were written")
+		return nil
 	}
 
-	fmt.Println("Conversion completed successfully")
+	fmt.Printf("Conversion completed successfully: %d converted, %d skipped in %s\n",
+		summary.SuccessCount, summary.SkippedCount, summary.Duration.Round(time.Millisecond))
+
+	if watch && !singleFile {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		fmt.Printf("Watching [%s] for changes, press Ctrl+C to stop\n", srcDirAbs)
+		return internal.WatchAndConvert(srcDirAbs, dstDirAbs, config, ctx)
+	}
 	return nil
 }
+
+// convertSingleFile converts the file srcPath to dstPath, or to a file of
+// the same name when dstPath is a directory, reporting it like
+// internal.ConvertPosts reports a directory
+func convertSingleFile(srcPath, dstPath string, cfg *internal.Config) (internal.ConversionSummary, error) {
+	if info, err := os.Stat(dstPath); err == nil && info.IsDir() {
+		dstPath = filepath.Join(dstPath, filepath.Base(srcPath))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return internal.ConvertSingleFile(ctx, internal.NewMarkdownConverter(cfg), srcPath, dstPath)
+}
+
+var progressMu sync.Mutex
+
+// printProgress reports to stderr that relPath is being converted. Files are
+// converted concurrently, so each line is written whole under a lock.
+func printProgress(relPath string) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	fmt.Fprintf(os.Stderr, "converting: %s\n", relPath)
+}
+
+// printDryRun prints the writes planned by a dry run
+func printDryRun(summary internal.ConversionSummary) {
+	for _, planned := range summary.Planned {
+		fmt.Printf("Would write %s -> %s\n", planned.SourcePath, planned.DestinationPath)
+		keys := make([]string, 0, len(planned.RenamedKeys))
+		for key := range planned.RenamedKeys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("    %s -> %s\n", key, planned.RenamedKeys[key])
+		}
+	}
+	for _, skipped := range summary.Skipped {
+		fmt.Printf("Would skip %s\n", skipped)
+	}
+}
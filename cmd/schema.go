@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pplmx/h2h/internal"
+	"github.com/spf13/cobra"
+)
+
+var schemaOutput string
+
+func newSchemaCmd() *cobra.Command {
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Generate a JSON Schema from the front matter found in a directory",
+		Long: `schema scans the Markdown files in the source directory and emits a JSON Schema
+describing the front matter fields actually observed: their types, whether they
+are present in every file (required) or only some (optional), and sample values.`,
+		RunE: runSchema,
+	}
+
+	flags := schemaCmd.Flags()
+	flags.StringVar(&srcDir, "src", "", "source directory containing Markdown files to scan (required)")
+	flags.StringVarP(&schemaOutput, "output", "o", "", "file to write the schema to (default stdout)")
+	flags.StringVar(&config.SourceFormat, "source-format", config.SourceFormat, "source FrontMatter format (yaml, toml, json, or auto to detect it per file)")
+	flags.StringVar(&config.FileExtension, "file-extension", config.FileExtension, "file extension for Markdown files")
+	flags.StringVar(&config.InputDelimiter, "input-delimiter", config.InputDelimiter, "line enclosing source front matter, e.g. ;;; (default: detect --- or +++)")
+	flags.StringVar(&config.CustomFrontMatterSeparator, "separator", config.CustomFrontMatterSeparator, "custom front matter delimiter, e.g. ===")
+
+	cobra.CheckErr(schemaCmd.MarkFlagRequired("src"))
+	return schemaCmd
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	schema, err := internal.GenerateSchema(srcDir, config)
+	if err != nil {
+		return fmt.Errorf("generating schema: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if schemaOutput != "" {
+		f, err := os.Create(schemaOutput)
+		if err != nil {
+			return fmt.Errorf("creating schema file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := internal.WriteSchema(w, schema); err != nil {
+		return fmt.Errorf("writing schema: %w", err)
+	}
+	return nil
+}
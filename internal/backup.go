@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// backupFile copies the file at path to path+suffix. A missing file needs
+// no backup.
+func backupFile(path, suffix string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading file to back up: %w", err)
+	}
+	if err := os.WriteFile(path+suffix, data, 0644); err != nil {
+		return fmt.Errorf("writing backup file: %w", err)
+	}
+	return nil
+}
+
+// FindBackups returns the files below dir whose name ends in suffix, which
+// RestoreBackups would restore
+func FindBackups(dir, suffix string) ([]string, error) {
+	if suffix == "" {
+		return nil, errors.New("backup suffix must not be empty")
+	}
+
+	var backups []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), suffix) && d.Name() != suffix {
+			backups = append(backups, path)
+		}
+		return nil
+	})
+	return backups, err
+}
+
+// RestoreBackups renames every file below dir whose name ends in suffix
+// back to its name without the suffix, replacing the converted file, and
+// returns the number of files restored
+func RestoreBackups(dir, suffix string) (int, error) {
+	backups, err := FindBackups(dir, suffix)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, path := range backups {
+		if err := os.Rename(path, strings.TrimSuffix(path, suffix)); err != nil {
+			return i, fmt.Errorf("restoring backup: %w", err)
+		}
+	}
+	return len(backups), nil
+}
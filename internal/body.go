@@ -0,0 +1,246 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+const (
+	defaultReadingSpeedWPM   = 200
+	defaultDescriptionMaxLen = 160
+)
+
+var selfClosingTagRe = regexp.MustCompile(`(?i)<(br|hr|img)\b([^>]*?)\s*/>`)
+
+// htmlCommentRe matches HTML comments, which end at the first -->
+var htmlCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// shortcodeEscaper quotes the openings of Hugo shortcodes, which are not
+// valid template actions, so that footers can contain them verbatim
+var shortcodeEscaper = strings.NewReplacer("{{<", `{{"{{<"}}`, "{{%", `{{"{{%"}}`)
+
+// transformBody applies the configured rewrites to the post body
+func (mc *MarkdownConverter) transformBody(body string) (string, error) {
+	if mc.cfg.ConvertSelfClosingHTMLTags {
+		body = selfClosingTagRe.ReplaceAllString(body, "<$1$2>")
+	}
+	if mc.cfg.ConvertCodeblockLanguages {
+		body = convertCodeblockLanguages(body, mc.cfg.CodeblockLanguageMap)
+	}
+	if mc.cfg.StripHTMLCommentsFromBody {
+		body = stripHTMLComments(body)
+	}
+	return normalizeLineBreaks(body, mc.cfg.LineBreakStrategy)
+}
+
+// stripHTMLComments removes the HTML comments outside fenced code blocks
+// of body, keeping Hugo's <!--more--> summary divider
+func stripHTMLComments(body string) string {
+	var sb strings.Builder
+	var text strings.Builder
+	flush := func() {
+		sb.WriteString(htmlCommentRe.ReplaceAllStringFunc(text.String(), func(comment string) string {
+			if strings.TrimSpace(comment[len("<!--"):len(comment)-len("-->")]) == "more" {
+				return comment
+			}
+			return ""
+		}))
+		text.Reset()
+	}
+
+	inFence := false
+	for _, line := range strings.SplitAfter(body, "\n") {
+		if isFence(line) {
+			inFence = !inFence
+			if inFence {
+				flush()
+			}
+			sb.WriteString(line)
+			continue
+		}
+		if inFence {
+			sb.WriteString(line)
+		} else {
+			text.WriteString(line)
+		}
+	}
+	flush()
+	return sb.String()
+}
+
+// convertCodeblockLanguages renames the language of every fenced code
+// block in body whose language is a key of languages, keeping the rest of
+// the info string, e.g. ```js {linenos=true} becomes ```javascript {linenos=true}
+func convertCodeblockLanguages(body string, languages map[string]string) string {
+	lines := strings.SplitAfter(body, "\n")
+	inFence := false
+	for i, line := range lines {
+		if !isFence(line) {
+			continue
+		}
+		inFence = !inFence
+		if !inFence {
+			continue
+		}
+
+		// the info string follows the fence characters and its first word
+		// is the language
+		trimmed := strings.TrimLeft(line, " \t")
+		info := strings.TrimLeft(trimmed, trimmed[:1])
+		prefix := line[:len(line)-len(info)]
+		language := strings.TrimSpace(info)
+		if end := strings.IndexAny(language, " \t{"); end >= 0 {
+			language = language[:end]
+		}
+		if renamed, ok := languages[language]; ok && language != "" {
+			lines[i] = prefix + strings.Replace(info, language, renamed, 1)
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// loadFooter parses cfg.ContentFooter on first use. It returns nil when no
+// footer is configured.
+func (mc *MarkdownConverter) loadFooter() (*template.Template, error) {
+	mc.footerOnce.Do(func() {
+		if mc.cfg.ContentFooter != "" {
+			// a missing key would otherwise be written as <no value>; the
+			// error makes appendFooter leave the footer out instead
+			mc.footer, mc.footerErr = template.New("footer").Option("missingkey=error").Parse(shortcodeEscaper.Replace(mc.cfg.ContentFooter))
+			if mc.footerErr != nil {
+				mc.footerErr = fmt.Errorf("parsing content footer: %w", mc.footerErr)
+			}
+		}
+	})
+	return mc.footer, mc.footerErr
+}
+
+// appendFooter appends cfg.ContentFooter, executed with the converted front
+// matter, to the post body on a line of its own. A post the footer cannot
+// be executed for, such as one without a field the footer refers to, is
+// left without it and a warning is logged.
+func (mc *MarkdownConverter) appendFooter(p *post) error {
+	footer, err := mc.loadFooter()
+	if err != nil || footer == nil {
+		return err
+	}
+
+	var sb strings.Builder
+	if err := footer.Execute(&sb, p.frontMatter); err != nil {
+		mc.cfg.logger().Warn("leaving out content footer", "file", p.sourcePath, "error", err)
+		return nil
+	}
+	if p.body != "" && !strings.HasSuffix(p.body, "\n") {
+		p.body += "\n"
+	}
+	p.body += sb.String()
+	if !strings.HasSuffix(p.body, "\n") {
+		p.body += "\n"
+	}
+	return nil
+}
+
+// injectBodyFields adds front matter fields derived from the post body
+func (mc *MarkdownConverter) injectBodyFields(p *post) error {
+	if mc.cfg.TitleFromH1 {
+		if title, _ := p.frontMatter["title"].(string); title == "" {
+			mc.titleFromH1(p)
+		}
+	}
+
+	if mc.cfg.AutoDescription {
+		if description, _ := p.frontMatter["description"].(string); description == "" {
+			if err := mc.descriptionFromBody(p); err != nil {
+				return err
+			}
+		}
+	}
+
+	words := len(strings.Fields(p.body))
+
+	if mc.cfg.GenerateReadingTime {
+		wpm := mc.cfg.ReadingSpeedWPM
+		if wpm <= 0 {
+			wpm = defaultReadingSpeedWPM
+		}
+		p.frontMatter["reading_time"] = int(math.Ceil(float64(words) / float64(wpm)))
+	}
+
+	if mc.cfg.GenerateWordCount {
+		p.frontMatter["word_count"] = words
+	}
+	return nil
+}
+
+// descriptionFromBody sets the description from the plain text of the first
+// body paragraph that has any, skipping headings and code blocks
+func (mc *MarkdownConverter) descriptionFromBody(p *post) error {
+	limit := mc.cfg.DescriptionMaxLen
+	if limit <= 0 {
+		limit = defaultDescriptionMaxLen
+	}
+
+	for _, paragraph := range bodyParagraphs(p.body) {
+		text, err := stripMarkdown(paragraph)
+		if err != nil {
+			return fmt.Errorf("extracting description: %w", err)
+		}
+		if text != "" {
+			p.frontMatter["description"] = truncateAtWord(text, limit)
+			return nil
+		}
+	}
+	return nil
+}
+
+// bodyParagraphs returns the blocks of consecutive lines of body that are
+// neither blank, headings nor inside code blocks
+func bodyParagraphs(body string) []string {
+	var paragraphs []string
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case isFence(line):
+			inFence = !inFence
+			flush()
+		case inFence:
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			flush()
+		default:
+			current = append(current, line)
+		}
+	}
+	flush()
+	return paragraphs
+}
+
+// titleFromH1 sets the title from the first level-one heading of the body,
+// removing that heading when cfg.RemoveH1FromBody is set
+func (mc *MarkdownConverter) titleFromH1(p *post) {
+	lines := strings.SplitAfter(p.body, "\n")
+	for _, i := range headingLines(lines) {
+		line := strings.TrimRight(lines[i], "\r\n")
+		if !strings.HasPrefix(line, "# ") {
+			continue
+		}
+
+		p.frontMatter["title"] = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+		mc.fmc.slugFromTitle(p.frontMatter)
+		if mc.cfg.RemoveH1FromBody {
+			p.body = strings.Join(append(lines[:i:i], lines[i+1:]...), "")
+		}
+		return
+	}
+}
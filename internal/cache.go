@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// frontMatterCache stores converted front matter maps on disk as JSON, keyed
+// by the SHA-256 of the source front matter. The key also covers the key map,
+// key aliases, the configuration and the patch file, so changing any of them
+// invalidates earlier entries.
+// The cache is best effort: unreadable entries count as misses and failed
+// writes are ignored.
+type frontMatterCache struct {
+	dir         string
+	fingerprint []byte
+}
+
+func newFrontMatterCache(cfg *Config, keyMap map[string]string, aliases map[string][]string) *frontMatterCache {
+	dir := cfg.CacheDir
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil
+		}
+		dir = filepath.Join(userCacheDir, "h2h")
+	}
+
+	// Marshaling a map sorts its keys, so the fingerprint is stable across runs
+	keyMapJSON, err := json.Marshal(keyMap)
+	if err != nil {
+		return nil
+	}
+	aliasesJSON, err := json.Marshal(aliases)
+	if err != nil {
+		return nil
+	}
+	cfgJSON, err := json.Marshal(cfg)
+	if err != nil {
+		return nil
+	}
+
+	h := sha256.New()
+	h.Write(keyMapJSON)
+	h.Write(aliasesJSON)
+	h.Write(cfgJSON)
+	h.Write([]byte(h2hVersion()))
+	if cfg.FrontMatterPatchFile != "" {
+		patch, err := os.ReadFile(cfg.FrontMatterPatchFile)
+		if err != nil {
+			return nil
+		}
+		h.Write(patch)
+	}
+	return &frontMatterCache{dir: dir, fingerprint: h.Sum(nil)}
+}
+
+func (c *frontMatterCache) path(frontMatter string) string {
+	h := sha256.New()
+	h.Write(c.fingerprint)
+	h.Write([]byte(frontMatter))
+	return filepath.Join(c.dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+func (c *frontMatterCache) load(frontMatter string) (map[string]interface{}, bool) {
+	data, err := os.ReadFile(c.path(frontMatter))
+	if err != nil {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var cached map[string]interface{}
+	if err := decoder.Decode(&cached); err != nil {
+		return nil, false
+	}
+	return decodeCachedValue(cached).(map[string]interface{}), true
+}
+
+func (c *frontMatterCache) store(frontMatter string, converted map[string]interface{}) {
+	data, err := json.Marshal(encodeCachedValue(converted))
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(c.path(frontMatter), data, 0644)
+}
+
+// Values that JSON cannot round-trip are wrapped in single-key objects
+const (
+	cachedTimeKey  = "$time"
+	cachedFloatKey = "$float"
+)
+
+// encodeCachedValue wraps dates and integral floats so their types survive the JSON round trip
+func encodeCachedValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		return map[string]interface{}{cachedTimeKey: v.Format(time.RFC3339Nano)}
+	case float64:
+		if v == math.Trunc(v) {
+			return map[string]interface{}{cachedFloatKey: v}
+		}
+	case map[string]interface{}:
+		encoded := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			encoded[key] = encodeCachedValue(item)
+		}
+		return encoded
+	case []interface{}:
+		encoded := make([]interface{}, len(v))
+		for i, item := range v {
+			encoded[i] = encodeCachedValue(item)
+		}
+		return encoded
+	}
+	return value
+}
+
+// decodeCachedValue reverses encodeCachedValue for a value decoded with UseNumber
+func decodeCachedValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		return jsonNumber(v)
+	case map[string]interface{}:
+		if len(v) == 1 {
+			if s, ok := v[cachedTimeKey].(string); ok {
+				if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+					return t
+				}
+			}
+			if n, ok := v[cachedFloatKey].(json.Number); ok {
+				f, _ := n.Float64()
+				return f
+			}
+		}
+		decoded := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			decoded[key] = decodeCachedValue(item)
+		}
+		return decoded
+	case []interface{}:
+		decoded := make([]interface{}, len(v))
+		for i, item := range v {
+			decoded[i] = decodeCachedValue(item)
+		}
+		return decoded
+	}
+	return value
+}
@@ -1,21 +1,57 @@
 package internal
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
 
 	"github.com/BurntSushi/toml"
+	jsonpatch "github.com/evanphx/json-patch/v5"
 	"golang.org/x/sync/errgroup"
 	"gopkg.in/yaml.v3"
 )
 
+// formatDetect and formatAuto are the SourceFormats that detect the format of each file
+const (
+	formatDetect = "detect"
+	formatAuto   = "auto"
+)
+
+// formatFrontMatterOnlyJSON is the TargetFormat that writes the converted
+// front matter of foo.md to foo.json and only the body to foo.md
+const formatFrontMatterOnlyJSON = "frontmatter-only-json"
+
+// detectsFormat reports whether format asks for per-file format detection
+func detectsFormat(format string) bool {
+	return format == formatDetect || format == formatAuto
+}
+
+// directionPassthrough converts between formats without renaming any keys
+const directionPassthrough = "passthrough"
+
+// front matter delimiters; "+++" is the Hugo convention for TOML
+const (
+	delimiterDashes = "---"
+	delimiterPluses = "+++"
+)
+
 // Config holds the configuration for the conversion process
 type Config struct {
 	SourceFormat        string
@@ -23,97 +59,1174 @@ type Config struct {
 	FileExtension       string
 	MaxConcurrency      int
 	ConversionDirection string
+	// OmitFrontMatterIfUnchanged skips writing destination files whose
+	// converted content is byte-identical to the source
+	OmitFrontMatterIfUnchanged bool
+	// NewKeyForUnmapped nests every key missing from the key map under this
+	// key (e.g. "params") instead of passing it through to the top level
+	NewKeyForUnmapped string
+	// FailOnUnmappedKeys rejects front matter with keys missing from the key
+	// map, except for those listed in IgnoreFields
+	FailOnUnmappedKeys bool
+	IgnoreFields       []string
+	// TruncateDescription caps the description at this many characters,
+	// 0 disables truncation
+	TruncateDescription int
+	// ConvertCategoriesToSections writes each post into a subdirectory named
+	// after its first category and drops that category from the front matter
+	ConvertCategoriesToSections bool
+	// GenerateReadingTime injects a reading_time field (in minutes)
+	// computed from the body at ReadingSpeedWPM words per minute
+	GenerateReadingTime bool
+	ReadingSpeedWPM     int
+	// GenerateWordCount injects a word_count field computed from the body
+	GenerateWordCount bool
+	// AutoDescription sets a missing description from the plain text of the
+	// first body paragraph, cut at a word boundary to DescriptionMaxLen
+	AutoDescription   bool
+	DescriptionMaxLen int
+	// SanitizeSlug normalises slug (or permalink) values to a URL-safe form
+	SanitizeSlug bool
+	// IgnoreErrors writes files that fail to convert unchanged and reports
+	// the failure as a warning instead of an error
+	IgnoreErrors bool
+	// AnnotateErrors prepends the conversion error as an HTML comment to
+	// files written because of IgnoreErrors
+	AnnotateErrors bool
+	// ForkOnError makes IgnoreErrors write a best-effort conversion, with
+	// keys renamed but values untransformed, and copy the source file to
+	// <destination>.original next to it so the two can be compared
+	ForkOnError bool
+	// FrontMatterStyle selects "block" or "flow" YAML style per field name
+	FrontMatterStyle map[string]string
+	// PostSortKey orders the converted posts by this front matter field
+	// and prefixes their file names with the position (0001-, 0002-, ...)
+	PostSortKey string
+	// FrontMatterEncryptFields lists fields whose string values are
+	// encrypted with AES-256-GCM using the hex-encoded 32-byte EncryptionKey
+	FrontMatterEncryptFields []string
+	EncryptionKey            string
+	// AlwaysQuoteStrings double-quotes every string value in YAML output
+	AlwaysQuoteStrings bool
+	// AutoScaleWorkers reduces the number of concurrent conversions while
+	// memory usage is above MemoryLimitMB and restores them as it recovers
+	AutoScaleWorkers bool
+	MemoryLimitMB    int
+	// SkipHiddenFiles ignores dot-prefixed files and directories
+	SkipHiddenFiles bool
+	// FrontMatterDiff, when non-nil, is called once per converted file with
+	// the original and converted front matter. It may be called from
+	// several goroutines at once and must not modify the maps.
+	FrontMatterDiff func(path string, before, after map[string]interface{}) `json:"-"`
+	// OnFileStart, when non-nil, is called with the path of every source
+	// file, relative to the source directory, as its conversion starts. It
+	// may be called from several goroutines at once.
+	OnFileStart func(relPath string) `json:"-"`
+	// ConvertSelfClosingHTMLTags rewrites <br/>, <hr/> and <img .../> in
+	// the body to their non-self-closing form
+	ConvertSelfClosingHTMLTags bool
+	// ConvertCodeblockLanguages renames the languages of fenced code blocks
+	// in the body according to CodeblockLanguageMap, e.g. js to javascript
+	ConvertCodeblockLanguages bool
+	CodeblockLanguageMap      map[string]string
+	// ConvertRelativeLinks rewrites relative link and image targets in the
+	// body, which are relative to the source file, to be relative to the
+	// destination file instead
+	ConvertRelativeLinks bool
+	// LineBreakStrategy rewrites single line breaks within body paragraphs:
+	// "softbreak" joins the lines, "hardbreak" turns them into Markdown
+	// hard breaks, and "none" leaves them as they are
+	LineBreakStrategy string
+	// StripHTMLCommentsFromBody removes <!-- ... --> comments outside code
+	// blocks from the body, except for the <!--more--> summary divider
+	StripHTMLCommentsFromBody bool
+	// ContentFooter is a text/template appended to every post body, executed
+	// with the converted front matter as data, e.g. {{ .title }}. Hugo
+	// shortcodes such as {{< related-posts >}} are copied as they are. Posts
+	// without a field the footer refers to get no footer and a warning;
+	// optional fields can be read with {{ with index . "author" }}.
+	ContentFooter string
+	// ForceTargetFormat always writes TargetFormat, even when SourceFormat
+	// is "detect" and would otherwise carry the detected format over
+	ForceTargetFormat bool
+	// KeepOriginalFrontMatter appends the source front matter as a comment
+	// block at the end of the converted front matter. JSON has no comments,
+	// so JSON front matter gets none.
+	KeepOriginalFrontMatter bool
+	// FrontMatterCommentChar prefixes the lines of front matter comments
+	FrontMatterCommentChar string
+	// SplitLongPosts splits posts whose body exceeds SplitAtLines lines at
+	// the nearest headings into <name>-part-<N> files (ConvertPosts only)
+	SplitLongPosts bool
+	SplitAtLines   int
+	// OutputBufferSize is the size of the write buffer for destination
+	// files, 0 uses the bufio default
+	OutputBufferSize int
+	// TitleFromH1 sets a missing or empty title from the first "# " heading
+	// of the body, removing the heading when RemoveH1FromBody is set
+	TitleFromH1      bool
+	RemoveH1FromBody bool
+	// SlugFromTitle generates a missing slug from the sanitised title
+	SlugFromTitle bool
+	// EnableCaching caches converted front matter in CacheDir (default
+	// <user cache dir>/h2h) so unchanged front matter is not converted again
+	EnableCaching bool
+	CacheDir      string
+	// JSONIndent is the number of spaces to indent JSON front matter by,
+	// 0 writes compact JSON
+	JSONIndent int
+	// YAMLIndent is the number of spaces to indent nested YAML by, 2 or 4;
+	// 0 uses the default of the YAML encoder, 4.
+	// TOMLIndent indents nested TOML tables; it is advisory, as TOML gives
+	// indentation no meaning and 0 writes none.
+	YAMLIndent int
+	TOMLIndent int
+	// FrontMatterFieldWidth pads top-level "key:" parts of YAML front
+	// matter to this many characters so values line up; 0 disables padding
+	FrontMatterFieldWidth int
+	// NewlineNormalization rewrites line endings in string values to "lf"
+	// or "crlf"; "none" leaves them untouched
+	NewlineNormalization string
+	// EmptyStringAsNull replaces empty string values with null, which TOML
+	// output omits
+	EmptyStringAsNull bool
+	// InputDelimiter is the line that encloses source front matter; empty
+	// detects "---", "+++" or a leading JSON object per file
+	InputDelimiter string
+	// OutputDelimiter forces the delimiter ("---" or "+++") written around
+	// front matter; empty keeps "+++" sources as they are and writes "---"
+	// for everything else
+	OutputDelimiter string
+	// CustomFrontMatterSeparator, such as "===" or ";;;", encloses front
+	// matter both when reading and writing, taking precedence over
+	// InputDelimiter and OutputDelimiter
+	CustomFrontMatterSeparator string
+	// OPAPolicyFile is a Rego policy converted front matter is checked
+	// against. Files for which data.h2h.deny is non-empty are rejected.
+	OPAPolicyFile string
+	// DryRun converts every file without writing anything and reports the
+	// writes that would have been made instead
+	DryRun bool
+	// GenerateTaxonomyFiles creates a minimal <taxonomy>/<term>/_index.md
+	// page for every tag and category that does not have one yet
+	GenerateTaxonomyFiles bool
+	// StripMarkdownFormatting renders the MarkdownFields values as Markdown
+	// and replaces them with their plain text
+	StripMarkdownFormatting bool
+	MarkdownFields          []string
+	// NormalizeDates rewrites the DateKeys values in DateLayout, which
+	// defaults to RFC 3339 for hexo2hugo and YYYY-MM-DD for hugo2hexo.
+	// RFC 3339 dates are written as native dates, others as strings
+	NormalizeDates bool
+	DateKeys       []string
+	DateLayout     string
+	// IncludeGlobs and ExcludeGlobs filter the converted files by their
+	// slash-separated path relative to the source directory, using ** for
+	// any number of directories. Exclusion takes precedence.
+	IncludeGlobs []string
+	ExcludeGlobs []string
+	// RequireFields lists the fields every converted post must have. Missing
+	// fields are set to the FrontMatterNullChar string, or fail the
+	// conversion when it is empty.
+	RequireFields       []string
+	FrontMatterNullChar string
+	// FrontMatterPatchFile is a JSON file holding an RFC 6902 JSON Patch
+	// that is applied to every converted front matter map
+	FrontMatterPatchFile string
+	// DigestFrontMatter adds a _digest field holding the HMAC-SHA256 of the
+	// written front matter under the hex-encoded DigestKey, computed as
+	// described by FrontMatterDigest
+	DigestFrontMatter bool
+	DigestKey         string
+	// AtomicWrites writes every output to a temporary file that is renamed
+	// into place once complete, so no partial file is ever visible. Turning
+	// it off can help on network filesystems where renames are expensive;
+	// in-place conversions are always atomic.
+	AtomicWrites bool
+	// BackupSuffix, when set, copies every existing destination file to its
+	// name plus this suffix before it is overwritten
+	BackupSuffix string
+	// InjectH2HVersion adds _h2h_version and _h2h_direction fields recording
+	// the h2h version and direction that converted the front matter
+	InjectH2HVersion bool
+	// FileRetries retries failed reads of source files and writes of
+	// destination files up to this many times, waiting RetryDelay before
+	// the first retry and twice as long before each further one, up to
+	// MaxRetryBackoff. FileTimeout, when set, bounds the time spent on one
+	// file operation including its retries.
+	FileRetries     int
+	RetryDelay      time.Duration
+	MaxRetryBackoff time.Duration
+	FileTimeout     time.Duration
+	// TemporaryDirectory holds the temporary files of atomic writes instead
+	// of the directory of each destination file. It should be on the same
+	// filesystem as the destination directory.
+	TemporaryDirectory string
+	// ReverseOrderProcessing converts the source files one at a time in
+	// reverse order of their paths, ignoring MaxConcurrency
+	ReverseOrderProcessing bool
+	// ShowProgress draws a progress bar of the converted files on stderr
+	// when it is a terminal
+	ShowProgress bool
+	// ImageKeys maps the hero image keys of Hexo themes to Hugo keys when
+	// converting hexo2hugo. String values mapped to images become lists,
+	// and the lists of several such keys are merged in key order.
+	ImageKeys map[string]string
+	// RewriteImagePaths turns site-absolute paths in ImageKeys values, such
+	// as /img/foo.png, into page bundle paths such as foo.png
+	RewriteImagePaths bool
+	// EnforceUTF8 fails source files that are not valid UTF-8 before they
+	// are parsed
+	EnforceUTF8 bool
+	// FlattenParams keeps keys that Hugo themes commonly read from params,
+	// such as author, at the top level instead of nesting them under params
+	FlattenParams bool
+	// FrontMatterMarshalPrecision rounds float values to this many decimal
+	// places before they are written; 0 leaves them unrounded
+	FrontMatterMarshalPrecision int
+	// GenerateOpenGraphFields copies converted front matter values to the
+	// Open Graph keys of OGFieldMap, which maps each Open Graph key to the
+	// key it is taken from. Open Graph keys already present are kept.
+	GenerateOpenGraphFields bool
+	OGFieldMap              map[string]string
+	// GenerateSchemaOrgFields adds a schema_org map describing the post as a
+	// Schema.org BlogPosting, for themes that render it as JSON-LD
+	GenerateSchemaOrgFields bool
+	// InjectKeys are set in every converted front matter after all other
+	// changes, overwriting keys of the same name
+	InjectKeys map[string]interface{}
+	// FrontMatterTypeHints converts the values of converted keys to "string",
+	// "int", "float", "bool" or "[]string", e.g. {"weight": "int"}
+	FrontMatterTypeHints map[string]string
+	// KeywordsFromTags copies the tags to a missing keywords field. With
+	// MergeKeywords, tags missing from existing keywords are appended.
+	KeywordsFromTags bool
+	MergeKeywords    bool
+	// KeyWhitelistMode drops every front matter key missing from the key map
+	// instead of passing it through
+	KeyWhitelistMode bool
+	// DropKeys are removed from the front matter after keys are renamed, so
+	// they name converted keys; dots reach into nested maps, e.g. params.toc
+	DropKeys []string
+	// NormalizeTags trims, re-cases and deduplicates the categories, tags
+	// and keywords lists, comparing values case-insensitively
+	NormalizeTags bool
+	// TagCase is the casing NormalizeTags applies: "lower" (also used when
+	// empty), "upper" or "title"
+	TagCase string
+	// RawListFields maps list fields, such as categories, to a field that
+	// receives a copy of the list as it was before value transforms and the
+	// patch file changed it. RawTagsField is short for an entry for tags.
+	RawListFields map[string]string
+	RawTagsField  string
+	// SkipExisting leaves destination files that already exist untouched
+	// and counts their sources as skipped
+	SkipExisting bool
+	// PreserveModTime sets the modification time of every written file to
+	// that of its source file
+	PreserveModTime bool
+	// ExportFrontMatterOnly writes only the converted front matter, with its
+	// delimiters, and drops the body
+	ExportFrontMatterOnly bool
+	// WatchDebounce is how long WatchAndConvert waits for further events on
+	// a file before converting it; WatchDelete removes the destination file
+	// when its source is deleted
+	WatchDebounce time.Duration
+	WatchDelete   bool
+	// ManifestFile, when set, receives a JSON list of the files ConvertPosts
+	// wrote for every source file. ComputeChecksum adds the SHA-256 of each
+	// source file to its entry as source_sha256.
+	ManifestFile    string
+	ComputeChecksum bool
+	// ChecksumFile, when set, receives the SHA-256 of every written file in
+	// sha256sum format, relative to the destination directory
+	ChecksumFile string
+	// WriteAtomicManifest writes the manifest only when every file converted
+	// successfully, leaving any previous manifest in place otherwise
+	WriteAtomicManifest bool
+	// EnableOpenMetrics writes conversion counters and timings to
+	// OpenMetricsFile in the OpenMetrics text format after ConvertPosts
+	EnableOpenMetrics bool
+	OpenMetricsFile   string
+	// MaxLineLength logs a warning for every written front matter line
+	// longer than this many characters; 0 disables the check
+	MaxLineLength int
+	// LogMaxSizeMB rotates the log file of the h2h command once it exceeds
+	// this many megabytes, keeping LogMaxBackups archives; 0 disables
+	// rotation
+	LogMaxSizeMB  int
+	LogMaxBackups int
+	// Logger receives diagnostic messages; nil means slog.Default()
+	Logger *slog.Logger `json:"-"`
+}
+
+// inputDelimiter returns the delimiter source front matter is split on,
+// or "" to detect it
+func (cfg *Config) inputDelimiter() string {
+	if cfg.CustomFrontMatterSeparator != "" {
+		return cfg.CustomFrontMatterSeparator
+	}
+	return cfg.InputDelimiter
+}
+
+// logger returns the configured logger, falling back to slog.Default()
+func (cfg *Config) logger() *slog.Logger {
+	if cfg.Logger != nil {
+		return cfg.Logger
+	}
+	return slog.Default()
 }
 
 // NewDefaultConfig returns a default configuration
 func NewDefaultConfig() *Config {
 	return &Config{
-		SourceFormat:        "yaml",
-		TargetFormat:        "yaml",
-		FileExtension:       ".md",
-		MaxConcurrency:      4,
-		ConversionDirection: "hexo2hugo",
+		SourceFormat:         "yaml",
+		TargetFormat:         "yaml",
+		FileExtension:        ".md",
+		MaxConcurrency:       4,
+		ConversionDirection:  "hexo2hugo",
+		ReadingSpeedWPM:      defaultReadingSpeedWPM,
+		DescriptionMaxLen:    defaultDescriptionMaxLen,
+		SkipHiddenFiles:      true,
+		AtomicWrites:         true,
+		PreserveModTime:      true,
+		FlattenParams:        true,
+		EnforceUTF8:          true,
+		RetryDelay:           100 * time.Millisecond,
+		MaxRetryBackoff:      5 * time.Second,
+		JSONIndent:           4,
+		YAMLIndent:           4,
+		TOMLIndent:           2,
+		NewlineNormalization: "none",
+		LineBreakStrategy:    "none",
+		MarkdownFields:       []string{"description", "excerpt"},
+		DateKeys:             []string{"date", "lastmod", "updated"},
+		WatchDebounce:        100 * time.Millisecond,
+		CodeblockLanguageMap: map[string]string{
+			"js":  "javascript",
+			"ts":  "typescript",
+			"py":  "python",
+			"rb":  "ruby",
+			"sh":  "bash",
+			"yml": "yaml",
+		},
+		FrontMatterCommentChar: "#",
+		ImageKeys: map[string]string{
+			"cover":       "images",
+			"cover_image": "images",
+			"thumbnail":   "images",
+			"feature":     "images",
+		},
+		OGFieldMap: map[string]string{
+			"og_title":       "title",
+			"og_description": "description",
+			"og_image":       "featured_image",
+		},
 	}
 }
 
 // FrontMatterConverter handles the conversion of front matter
 type FrontMatterConverter struct {
+	cfg          *Config
 	keyMap       map[string]string
 	sourceFormat string
 	targetFormat string
+	cache        *frontMatterCache
+	// aliases maps canonical source keys to their alternative spellings
+	aliases map[string][]string
+	// valueTransformers rewrite the values of renamed keys, by source key
+	valueTransformers map[string]func(interface{}) interface{}
+
+	patchOnce sync.Once
+	patch     jsonpatch.Patch
+	patchErr  error
 }
 
 // NewFrontMatterConverter creates a new FrontMatterConverter
 func NewFrontMatterConverter(cfg *Config) *FrontMatterConverter {
 	var keyMap map[string]string
-	if cfg.ConversionDirection == "hexo2hugo" {
+	var valueTransformers map[string]func(interface{}) interface{}
+	switch cfg.ConversionDirection {
+	case "hexo2hugo":
 		keyMap = getHexoToHugoKeyMap()
-	} else {
+		if cfg.FlattenParams {
+			keyMap = flattenParamKeys(keyMap)
+		}
+		for from, to := range cfg.ImageKeys {
+			keyMap[from] = to
+		}
+		valueTransformers = map[string]func(interface{}) interface{}{"published": invertBool}
+		for from, to := range keyMap {
+			if hugoListKeys[to] {
+				valueTransformers[from] = wrapInList
+			}
+		}
+		if cfg.RewriteImagePaths {
+			for from := range cfg.ImageKeys {
+				valueTransformers[from] = chainValueTransformers(rewriteImagePaths, valueTransformers[from])
+			}
+		}
+	case directionPassthrough:
+		// a nil key map is the identity map, see mapKey
+	default:
 		keyMap = getHugoToHexoKeyMap()
+		if cfg.FlattenParams {
+			keyMap = flattenParamKeys(keyMap)
+		}
+		valueTransformers = map[string]func(interface{}) interface{}{"draft": invertBool}
+		for from := range keyMap {
+			if hugoListKeys[from] {
+				valueTransformers[from] = unwrapSingleton
+			}
+		}
+	}
+
+	targetFormat := cfg.TargetFormat
+	if targetFormat == formatFrontMatterOnlyJSON {
+		targetFormat = "json"
+	}
+
+	fmc := &FrontMatterConverter{
+		cfg:               cfg,
+		keyMap:            keyMap,
+		sourceFormat:      cfg.SourceFormat,
+		targetFormat:      targetFormat,
+		valueTransformers: valueTransformers,
 	}
+	if cfg.EnableCaching {
+		fmc.cache = newFrontMatterCache(cfg, keyMap, nil)
+	}
+	return fmc
+}
+
+// KeyMap returns a copy of the map from source to target keys in use.
+// Dots in target keys nest them in maps. It is nil for passthrough.
+func (fmc *FrontMatterConverter) KeyMap() map[string]string {
+	return maps.Clone(fmc.keyMap)
+}
 
-	return &FrontMatterConverter{
-		keyMap:       keyMap,
-		sourceFormat: cfg.SourceFormat,
-		targetFormat: cfg.TargetFormat,
+// AddKeyAlias makes alias a recognised spelling of the source key
+// canonical: a source map that has alias but not canonical is converted as
+// if alias were canonical. A canonical key may have several aliases, which
+// are tried in the order they were added. Aliases must be added before
+// converting.
+func (fmc *FrontMatterConverter) AddKeyAlias(canonical, alias string) {
+	if fmc.aliases == nil {
+		fmc.aliases = make(map[string][]string)
+	}
+	fmc.aliases[canonical] = append(fmc.aliases[canonical], alias)
+	if fmc.cache != nil {
+		fmc.cache = newFrontMatterCache(fmc.cfg, fmc.keyMap, fmc.aliases)
 	}
 }
 
+// resolveAliases returns frontMatter with aliased keys renamed to their
+// canonical keys. frontMatter itself is left unchanged.
+func (fmc *FrontMatterConverter) resolveAliases(frontMatter map[string]interface{}) map[string]interface{} {
+	var resolved map[string]interface{}
+	for canonical, aliases := range fmc.aliases {
+		if _, ok := frontMatter[canonical]; ok {
+			continue
+		}
+		for _, alias := range aliases {
+			value, ok := frontMatter[alias]
+			if !ok {
+				continue
+			}
+			if resolved == nil {
+				resolved = make(map[string]interface{}, len(frontMatter))
+				for key, value := range frontMatter {
+					resolved[key] = value
+				}
+			}
+			delete(resolved, alias)
+			resolved[canonical] = value
+			fmc.cfg.logger().Debug("using key alias", "alias", alias, "key", canonical)
+			break
+		}
+	}
+	if resolved == nil {
+		return frontMatter
+	}
+	return resolved
+}
+
 // ConvertFrontMatter converts the front matter from source format to target format
 func (fmc *FrontMatterConverter) ConvertFrontMatter(frontMatter string) (string, error) {
-	var frontMatterMap map[string]interface{}
-	if err := unmarshalFrontMatter(fmc.sourceFormat, []byte(frontMatter), &frontMatterMap); err != nil {
-		return "", fmt.Errorf("unmarshaling front matter: %w", err)
+	frontMatterMap, sourceFormat, err := fmc.parseFrontMatter(frontMatter)
+	if err != nil {
+		return "", err
 	}
 
-	convertedMap := make(map[string]interface{}, len(frontMatterMap))
-	for key, value := range frontMatterMap {
-		if convertedKey, ok := fmc.keyMap[key]; ok {
-			convertedMap[convertedKey] = value
-		} else {
-			convertedMap[key] = value
+	convertedMap, err := fmc.convertCached(frontMatter, frontMatterMap)
+	if err != nil {
+		return "", err
+	}
+	if convertedMap, err = fmc.withDigest(convertedMap); err != nil {
+		return "", err
+	}
+
+	rendered, err := fmc.renderFrontMatter(convertedMap, fmc.outputFormat(sourceFormat))
+	if err != nil {
+		return "", err
+	}
+	return wrapFrontMatter(rendered, fmc.outputFormat(sourceFormat), fmc.outputDelimiter("")), nil
+}
+
+// parseFrontMatter unmarshals the front matter and returns it together with
+// the source format it was parsed as
+func (fmc *FrontMatterConverter) parseFrontMatter(frontMatter string) (map[string]interface{}, string, error) {
+	frontMatterMap, format, err := decodeFrontMatter(fmc.sourceFormat, []byte(frontMatter))
+	if err != nil {
+		return nil, "", fmt.Errorf("unmarshaling front matter: %w", err)
+	}
+	return frontMatterMap, format, nil
+}
+
+// convertCached converts the parsed front matter, consulting the on-disk
+// cache first when caching is enabled
+func (fmc *FrontMatterConverter) convertCached(frontMatter string, frontMatterMap map[string]interface{}) (map[string]interface{}, error) {
+	if fmc.cache == nil {
+		return fmc.ConvertFrontMatterMap(frontMatterMap)
+	}
+
+	if cached, ok := fmc.cache.load(frontMatter); ok {
+		return cached, nil
+	}
+
+	convertedMap, err := fmc.ConvertFrontMatterMap(frontMatterMap)
+	if err != nil {
+		return nil, err
+	}
+	fmc.cache.store(frontMatter, convertedMap)
+	return convertedMap, nil
+}
+
+// outputFormat returns the format to write front matter parsed as sourceFormat in.
+// Detected formats carry over to the output unless ForceTargetFormat is set.
+func (fmc *FrontMatterConverter) outputFormat(sourceFormat string) string {
+	if detectsFormat(fmc.sourceFormat) && !fmc.cfg.ForceTargetFormat && fmc.cfg.TargetFormat != formatFrontMatterOnlyJSON {
+		return sourceFormat
+	}
+	return fmc.targetFormat
+}
+
+// renderFrontMatter marshals the front matter in the given format, without delimiters
+func (fmc *FrontMatterConverter) renderFrontMatter(frontMatter map[string]interface{}, format string) (string, error) {
+	var data interface{} = frontMatter
+	if format == "yaml" && fmc.usesYAMLNode() {
+		node, err := fmc.yamlNode(frontMatter)
+		if err != nil {
+			return "", fmt.Errorf("marshaling front matter: %w", err)
 		}
+		data = node
 	}
 
 	var buf bytes.Buffer
-	if err := marshalFrontMatter(fmc.targetFormat, &buf, convertedMap); err != nil {
+	if err := fmc.marshalFrontMatter(format, &buf, data); err != nil {
 		return "", fmt.Errorf("marshaling front matter: %w", err)
 	}
 
-	return fmt.Sprintf("---\n%s---", buf.String()), nil
+	if format == "yaml" && fmc.cfg.FrontMatterFieldWidth > 0 {
+		return padYAMLKeys(buf.String(), fmc.cfg.FrontMatterFieldWidth)
+	}
+	return buf.String(), nil
+}
+
+// outputDelimiter returns the delimiter to write front matter split on
+// sourceDelimiter with. CustomFrontMatterSeparator and then OutputDelimiter
+// take precedence over the source.
+func (fmc *FrontMatterConverter) outputDelimiter(sourceDelimiter string) string {
+	if fmc.cfg.CustomFrontMatterSeparator != "" {
+		return fmc.cfg.CustomFrontMatterSeparator
+	}
+	if fmc.cfg.OutputDelimiter != "" {
+		return fmc.cfg.OutputDelimiter
+	}
+	if sourceDelimiter == delimiterPluses {
+		return delimiterPluses
+	}
+	return delimiterDashes
+}
+
+// wrapFrontMatter surrounds rendered front matter with delimiters. JSON
+// front matter is a bare object and needs none.
+func wrapFrontMatter(rendered, format, delimiter string) string {
+	if format == "json" {
+		return strings.TrimSuffix(rendered, "\n")
+	}
+	return fmt.Sprintf("%s\n%s%s", delimiter, rendered, delimiter)
+}
+
+// ConvertFrontMatterMap renames the keys of an unmarshaled front matter map
+func (fmc *FrontMatterConverter) ConvertFrontMatterMap(frontMatter map[string]interface{}) (map[string]interface{}, error) {
+	frontMatter = fmc.resolveAliases(frontMatter)
+	frontMatter = fmc.liftNestedKeys(frontMatter)
+	if fmc.cfg.FailOnUnmappedKeys {
+		if err := fmc.checkUnmappedKeys(frontMatter); err != nil {
+			return nil, err
+		}
+	}
+
+	convertedMap := fmc.renameKeys(frontMatter)
+	for _, key := range fmc.cfg.DropKeys {
+		if _, remaining, ok := removeNestedKey(convertedMap, strings.Split(key, ".")); ok {
+			convertedMap = remaining
+		}
+	}
+	if fmc.cfg.KeywordsFromTags {
+		keywordsFromTags(convertedMap, fmc.cfg.MergeKeywords)
+	}
+	fmc.copyRawLists(convertedMap)
+	if err := fmc.transformValues(convertedMap); err != nil {
+		return nil, err
+	}
+	if fmc.cfg.GenerateOpenGraphFields {
+		for ogKey, key := range fmc.cfg.OGFieldMap {
+			if value, ok := convertedMap[key]; ok {
+				if _, exists := convertedMap[ogKey]; !exists {
+					convertedMap[ogKey] = copyValue(value)
+				}
+			}
+		}
+	}
+	if _, exists := convertedMap[schemaOrgKey]; fmc.cfg.GenerateSchemaOrgFields && !exists {
+		convertedMap[schemaOrgKey] = schemaOrgFields(convertedMap)
+	}
+	if fmc.cfg.InjectH2HVersion {
+		convertedMap[versionKey] = h2hVersion()
+		convertedMap[directionKey] = fmc.cfg.ConversionDirection
+	}
+
+	convertedMap, err := fmc.applyPatch(convertedMap)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range fmc.cfg.InjectKeys {
+		convertedMap[key] = copyValue(value)
+	}
+	return convertedMap, nil
+}
+
+// checkUnmappedKeys returns an error listing the keys of frontMatter that
+// are neither in the key map nor in IgnoreFields
+func (fmc *FrontMatterConverter) checkUnmappedKeys(frontMatter map[string]interface{}) error {
+	var unmapped []string
+	for key := range frontMatter {
+		if _, ok := fmc.mapKey(key); !ok && !slices.Contains(fmc.cfg.IgnoreFields, key) {
+			unmapped = append(unmapped, key)
+		}
+	}
+	if len(unmapped) > 0 {
+		sort.Strings(unmapped)
+		return fmt.Errorf("unmapped front matter keys: %s", strings.Join(unmapped, ", "))
+	}
+	return nil
+}
+
+// renameKeys renames the keys of frontMatter, whose aliases must already be
+// resolved, nesting unmapped keys under NewKeyForUnmapped, without
+// transforming any values. Keys are renamed in sorted order, so when
+// several keys map to the same key the result is the same every time: list
+// values are merged, and otherwise the first key wins.
+func (fmc *FrontMatterConverter) renameKeys(frontMatter map[string]interface{}) map[string]interface{} {
+	convertedMap := make(map[string]interface{}, len(frontMatter))
+	unmapped := make(map[string]interface{})
+	nested := make(map[string]interface{})
+	for _, key := range slices.Sorted(maps.Keys(frontMatter)) {
+		value := frontMatter[key]
+		if convertedKey, ok := fmc.mapKey(key); ok {
+			if transform, ok := fmc.valueTransformers[key]; ok {
+				value = transform(value)
+			}
+			renamed := convertedMap
+			if strings.Contains(convertedKey, ".") {
+				renamed = nested
+			}
+			if existing, ok := renamed[convertedKey]; ok {
+				renamed[convertedKey] = mergeRenamedValues(existing, value)
+			} else {
+				renamed[convertedKey] = copyValue(value)
+			}
+		} else if !fmc.cfg.KeyWhitelistMode {
+			unmapped[key] = copyValue(value)
+		}
+	}
+
+	if nestKey := fmc.cfg.NewKeyForUnmapped; nestKey != "" && len(unmapped) > 0 {
+		nested := make(map[string]interface{}, len(unmapped))
+		if existing, ok := unmapped[nestKey].(map[string]interface{}); ok {
+			delete(unmapped, nestKey)
+			for key, value := range existing {
+				nested[key] = value
+			}
+		}
+		for key, value := range unmapped {
+			nested[key] = value
+		}
+		convertedMap[nestKey] = nested
+	} else {
+		for key, value := range unmapped {
+			convertedMap[key] = value
+		}
+	}
+
+	// nested keys go in last so that they merge into maps such as params
+	// created above instead of being replaced by them
+	for key, value := range nested {
+		setNestedKey(convertedMap, key, value)
+	}
+	return convertedMap
+}
+
+// copyRawLists copies the fields of RawListFields and RawTagsField to
+// their raw fields, reading every field before any copy is written
+func (fmc *FrontMatterConverter) copyRawLists(frontMatter map[string]interface{}) {
+	rawFields := fmc.cfg.RawListFields
+	if fmc.cfg.RawTagsField != "" {
+		rawFields = maps.Clone(rawFields)
+		if rawFields == nil {
+			rawFields = make(map[string]string, 1)
+		}
+		rawFields["tags"] = fmc.cfg.RawTagsField
+	}
+
+	raw := make(map[string]interface{}, len(rawFields))
+	for key, rawKey := range rawFields {
+		if value, ok := frontMatter[key]; ok && rawKey != "" {
+			raw[rawKey] = copyValue(value)
+		}
+	}
+	maps.Copy(frontMatter, raw)
+}
+
+// mergeRenamedValues combines the values of two keys renamed to the same
+// key: the items of a second list missing from a first one are appended
+// to it, and any other first value is kept
+func mergeRenamedValues(existing, value interface{}) interface{} {
+	list, ok := existing.([]interface{})
+	if !ok {
+		return existing
+	}
+	more, ok := value.([]interface{})
+	if !ok {
+		return existing
+	}
+	for _, item := range more {
+		if !slices.Contains(list, item) {
+			list = append(list, copyValue(item))
+		}
+	}
+	return list
+}
+
+// liftNestedKeys moves the values of dot-separated source keys of the key
+// map, such as params.author, to a top-level key of that name. The maps of
+// frontMatter are copied rather than modified, and emptied maps removed.
+func (fmc *FrontMatterConverter) liftNestedKeys(frontMatter map[string]interface{}) map[string]interface{} {
+	lifted := frontMatter
+	for key := range fmc.keyMap {
+		if !strings.Contains(key, ".") {
+			continue
+		}
+		value, remaining, ok := removeNestedKey(lifted, strings.Split(key, "."))
+		if !ok {
+			continue
+		}
+		lifted = remaining
+		lifted[key] = value
+	}
+	return lifted
+}
+
+// removeNestedKey returns the value at path below m and a copy of m
+// without it, dropping maps left empty. ok is false when path is missing.
+func removeNestedKey(m map[string]interface{}, path []string) (interface{}, map[string]interface{}, bool) {
+	value, ok := m[path[0]]
+	if !ok {
+		return nil, nil, false
+	}
+	remaining := maps.Clone(m)
+	delete(remaining, path[0])
+	if len(path) == 1 {
+		return value, remaining, true
+	}
+
+	child, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, nil, false
+	}
+	value, rest, ok := removeNestedKey(child, path[1:])
+	if !ok {
+		return nil, nil, false
+	}
+	if len(rest) > 0 {
+		remaining[path[0]] = rest
+	}
+	return value, remaining, true
+}
+
+// setNestedKey sets the dot-separated key in m, creating intermediate maps
+// as needed and replacing intermediate values that are not maps
+func setNestedKey(m map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := m[part].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			m[part] = child
+		}
+		m = child
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+// mapKey returns the key that key is renamed to and whether it is mapped.
+// Every key maps to itself in passthrough mode.
+func (fmc *FrontMatterConverter) mapKey(key string) (string, bool) {
+	if fmc.keyMap == nil {
+		return key, true
+	}
+	convertedKey, ok := fmc.keyMap[key]
+	return convertedKey, ok
+}
+
+// renamedKeys returns the keys of frontMatter that the key map renames,
+// mapped to their new names
+func (fmc *FrontMatterConverter) renamedKeys(frontMatter map[string]interface{}) map[string]string {
+	renamed := make(map[string]string)
+	for key := range frontMatter {
+		if convertedKey, ok := fmc.mapKey(key); ok && convertedKey != key {
+			renamed[key] = convertedKey
+		}
+	}
+	return renamed
 }
 
 // MarkdownConverter handles the conversion of markdown files
 type MarkdownConverter struct {
+	cfg *Config
 	fmc *FrontMatterConverter
+
+	policyOnce sync.Once
+	policy     *policy
+	policyErr  error
+
+	footerOnce sync.Once
+	footer     *template.Template
+	footerErr  error
 }
 
 // NewMarkdownConverter creates a new MarkdownConverter
 func NewMarkdownConverter(cfg *Config) *MarkdownConverter {
-	return &MarkdownConverter{fmc: NewFrontMatterConverter(cfg)}
+	return &MarkdownConverter{cfg: cfg, fmc: NewFrontMatterConverter(cfg)}
+}
+
+// loadPolicy compiles cfg.OPAPolicyFile on first use. It returns nil when
+// no policy is configured.
+func (mc *MarkdownConverter) loadPolicy() (*policy, error) {
+	mc.policyOnce.Do(func() {
+		if mc.cfg.OPAPolicyFile != "" {
+			mc.policy, mc.policyErr = loadPolicy(mc.cfg.OPAPolicyFile)
+		}
+	})
+	return mc.policy, mc.policyErr
 }
 
 // ConvertMarkdown converts a single markdown file
 func (mc *MarkdownConverter) ConvertMarkdown(r io.Reader, w io.Writer) error {
+	return mc.ConvertMarkdownWithPaths("", "", r, w)
+}
+
+// ConvertMarkdownWithPaths converts a single markdown file like
+// ConvertMarkdown, given the paths it is read from and written to, which
+// ConvertRelativeLinks needs to rewrite relative links
+func (mc *MarkdownConverter) ConvertMarkdownWithPaths(srcPath, dstPath string, r io.Reader, w io.Writer) error {
 	content, err := io.ReadAll(r)
 	if err != nil {
 		return fmt.Errorf("reading content: %w", err)
 	}
 
-	parts := strings.SplitN(string(content), "---", 3)
-	if len(parts) < 3 {
-		return errors.New("parsing content: invalid hexo/hugo markdown format")
+	p, err := mc.convert(context.Background(), content, srcPath, dstPath)
+	if err != nil {
+		return err
+	}
+
+	return mc.writePost(w, p)
+}
+
+// post holds a converted markdown file before it is written
+type post struct {
+	// sourcePath is the file the post was read from, if known
+	sourcePath string
+	// rawFrontMatter and original are the front matter as it appeared in
+	// the source and as it was parsed from it
+	rawFrontMatter string
+	original       map[string]interface{}
+	frontMatter    map[string]interface{}
+	body           string
+	// sourceFormat is the format the front matter was parsed as
+	sourceFormat string
+	// format and delimiter are the front matter format and delimiter the
+	// post is written with
+	format    string
+	delimiter string
+	// section is the subdirectory the post should be written to, if any
+	section string
+}
+
+// convert converts content read from srcPath, to be written to dstPath.
+// The paths may be empty when they are unknown. ctx cancels the policy
+// check.
+func (mc *MarkdownConverter) convert(ctx context.Context, content []byte, srcPath, dstPath string) (*post, error) {
+	frontMatter, body, delimiter, err := splitContent(string(content), mc.cfg.inputDelimiter())
+	if err != nil {
+		return nil, fmt.Errorf("parsing content: %w", err)
+	}
+
+	frontMatterMap, sourceFormat, err := mc.fmc.parseFrontMatter(frontMatter)
+	if err != nil {
+		return nil, fmt.Errorf("converting front matter: %w", err)
+	}
+
+	convertedMap, err := mc.fmc.convertCached(frontMatter, frontMatterMap)
+	if err != nil {
+		return nil, fmt.Errorf("converting front matter: %w", err)
+	}
+
+	policy, err := mc.loadPolicy()
+	if err != nil {
+		return nil, err
+	}
+	if policy != nil {
+		if err := policy.check(ctx, convertedMap); err != nil {
+			return nil, err
+		}
+	}
+
+	body, err = mc.transformBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &post{
+		sourcePath:     srcPath,
+		rawFrontMatter: frontMatter,
+		original:       frontMatterMap,
+		frontMatter:    convertedMap,
+		body:           body,
+		sourceFormat:   sourceFormat,
+		format:         mc.fmc.outputFormat(sourceFormat),
+		delimiter:      mc.fmc.outputDelimiter(delimiter),
+	}
+	if err := mc.injectBodyFields(p); err != nil {
+		return nil, err
+	}
+	if mc.cfg.ConvertCategoriesToSections {
+		p.section = extractSection(convertedMap)
+	}
+	if mc.cfg.ConvertRelativeLinks && srcPath != "" && dstPath != "" {
+		dstDir := filepath.Join(filepath.Dir(dstPath), p.section)
+		p.body = convertRelativeLinks(p.body, filepath.Dir(srcPath), dstDir)
+	}
+	if err := mc.appendFooter(p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// convertBestEffort converts content with its keys renamed but without the
+// value transforms, patch and policy that may have made mc.convert fail
+func (mc *MarkdownConverter) convertBestEffort(content []byte) ([]byte, error) {
+	frontMatter, body, delimiter, err := splitContent(string(content), mc.cfg.inputDelimiter())
+	if err != nil {
+		return nil, err
+	}
+	frontMatterMap, sourceFormat, err := mc.fmc.parseFrontMatter(frontMatter)
+	if err != nil {
+		return nil, err
 	}
 
-	convertedFrontMatter, err := mc.fmc.ConvertFrontMatter(parts[1])
+	var buf bytes.Buffer
+	err = mc.writePost(&buf, &post{
+		rawFrontMatter: frontMatter,
+		original:       frontMatterMap,
+		frontMatter:    mc.fmc.renameKeys(mc.fmc.resolveAliases(frontMatterMap)),
+		body:           body,
+		sourceFormat:   sourceFormat,
+		format:         mc.fmc.outputFormat(sourceFormat),
+		delimiter:      mc.fmc.outputDelimiter(delimiter),
+	})
+	return buf.Bytes(), err
+}
+
+func (mc *MarkdownConverter) writePost(w io.Writer, p *post) error {
+	frontMatter, err := mc.fmc.withDigest(p.frontMatter)
+	if err != nil {
+		return fmt.Errorf("signing front matter: %w", err)
+	}
+
+	convertedFrontMatter, err := mc.fmc.renderFrontMatter(frontMatter, p.format)
 	if err != nil {
 		return fmt.Errorf("converting front matter: %w", err)
 	}
 
-	_, err = fmt.Fprintf(w, "%s\n\n%s", convertedFrontMatter, parts[2])
+	mc.checkLineLength(p, convertedFrontMatter)
+
+	if mc.cfg.KeepOriginalFrontMatter {
+		if p.format == "json" {
+			mc.cfg.logger().Warn("JSON front matter cannot hold comments, dropping original front matter", "file", p.sourcePath)
+		} else {
+			convertedFrontMatter += commentOut("ORIGINAL FRONT MATTER:\n"+strings.Trim(p.rawFrontMatter, "\n"), mc.cfg.FrontMatterCommentChar)
+		}
+	}
+
+	body := p.body
+	if mc.cfg.ExportFrontMatterOnly {
+		body = "\n"
+	}
+	_, err = fmt.Fprintf(w, "%s%s", wrapFrontMatter(convertedFrontMatter, p.format, p.delimiter), body)
 	return err
 }
 
+// checkLineLength warns about rendered front matter lines longer than
+// cfg.MaxLineLength. Line numbers count the opening delimiter.
+func (mc *MarkdownConverter) checkLineLength(p *post, rendered string) {
+	limit := mc.cfg.MaxLineLength
+	if limit <= 0 {
+		return
+	}
+
+	offset := 2
+	if p.format == "json" {
+		offset = 1
+	}
+	for i, line := range strings.Split(strings.TrimSuffix(rendered, "\n"), "\n") {
+		if length := utf8.RuneCountInString(line); length > limit {
+			mc.cfg.logger().Warn("front matter line too long", "file", p.sourcePath, "line", i+offset, "length", length)
+		}
+	}
+}
+
+// commentOut turns every line of text into a comment line starting with
+// char, or with # when char is empty
+func commentOut(text, char string) string {
+	if char == "" {
+		char = "#"
+	}
+	var sb strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		sb.WriteString(strings.TrimRight(char+" "+line, " "))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// extractSection removes the first category from the front matter and
+// returns it as a directory name, keeping any remaining categories
+func extractSection(frontMatter map[string]interface{}) string {
+	var section string
+	switch categories := frontMatter["categories"].(type) {
+	case string:
+		section = categories
+		delete(frontMatter, "categories")
+	case []interface{}:
+		if len(categories) == 0 {
+			return ""
+		}
+		section = fmt.Sprint(categories[0])
+		if len(categories) > 1 {
+			frontMatter["categories"] = categories[1:]
+		} else {
+			delete(frontMatter, "categories")
+		}
+	}
+
+	section = strings.TrimSpace(strings.NewReplacer("/", "-", "\\", "-").Replace(section))
+	if section == "." || section == ".." {
+		return ""
+	}
+	return section
+}
+
+// SplitContent splits markdown content into its front matter and body, and
+// returns the delimiter ("---" or "+++") the front matter was enclosed in.
+// Content starting with "{" has JSON front matter and no delimiter.
+func SplitContent(content string) (frontMatter, body, delimiter string, err error) {
+	return splitContent(content, "")
+}
+
+// splitContent is SplitContent with the delimiter given, or detected when
+// delimiter is empty
+func splitContent(content, delimiter string) (string, string, string, error) {
+	explicit := delimiter != ""
+	if !explicit {
+		trimmed := strings.TrimLeft(content, " \t\r\n")
+		if strings.HasPrefix(trimmed, "{") {
+			frontMatter, body, err := splitJSONFrontMatter(trimmed)
+			return frontMatter, body, "", err
+		}
+
+		delimiter = delimiterDashes
+		if strings.HasPrefix(trimmed, delimiterPluses) {
+			delimiter = delimiterPluses
+		}
+	}
+
+	frontMatter, body, err := SplitMarkdown([]byte(content), delimiter)
+	if err != nil {
+		if explicit && countDelimiterLines(content, delimiter) < 2 {
+			return "", "", "", fmt.Errorf("front matter delimiter %q must appear at least twice: %w", delimiter, err)
+		}
+		return "", "", "", err
+	}
+	return string(frontMatter), string(body), delimiter, nil
+}
+
+// countDelimiterLines returns the number of lines of content that are delimiter
+func countDelimiterLines(content, delimiter string) int {
+	count := 0
+	for _, line := range strings.Split(content, "\n") {
+		if isDelimiterLine([]byte(line), delimiter) {
+			count++
+		}
+	}
+	return count
+}
+
+// SplitMarkdown splits content into the front matter enclosed by lines
+// consisting of delimiter alone and the body after it. Only the delimiters
+// are cut out, so delimiter+frontMatter+delimiter+body reproduces content
+// after any leading blank lines. Lines in the front matter or body that
+// merely contain the delimiter, such as horizontal rules, are left alone.
+func SplitMarkdown(content []byte, delimiter string) (frontMatter, body []byte, err error) {
+	content = bytes.TrimLeft(content, " \t\r\n")
+	first, rest, found := bytes.Cut(content, []byte("\n"))
+	if !found || !isDelimiterLine(first, delimiter) {
+		return nil, nil, errors.New("invalid hexo/hugo markdown format")
+	}
+
+	start := len(delimiter)
+	for offset := len(first) + 1; ; {
+		line, next, found := bytes.Cut(rest, []byte("\n"))
+		if isDelimiterLine(line, delimiter) {
+			return content[start:offset], content[offset+len(delimiter):], nil
+		}
+		if !found {
+			return nil, nil, errors.New("invalid hexo/hugo markdown format")
+		}
+		offset += len(line) + 1
+		rest = next
+	}
+}
+
+// isDelimiterLine reports whether line is delimiter, ignoring trailing
+// whitespace
+func isDelimiterLine(line []byte, delimiter string) bool {
+	return string(bytes.TrimRight(line, " \t\r")) == delimiter
+}
+
 // ConversionError represents an error that occurred during the conversion process
 type ConversionError struct {
 	SourceFile string
@@ -124,25 +1237,141 @@ func (e *ConversionError) Error() string {
 	return fmt.Sprintf("converting file %s: %v", e.SourceFile, e.Err)
 }
 
-// ConvertPosts converts all markdown posts in the source directory to the target format
-func ConvertPosts(srcDir, dstDir string, cfg *Config) error {
-	if err := os.MkdirAll(dstDir, 0755); err != nil {
-		return fmt.Errorf("creating destination directory %s: %w", dstDir, err)
+// Unwrap returns the underlying error, so errors.Is(err, fs.ErrNotExist)
+// and the like see through a ConversionError
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// ConversionErrorList is the error ConvertPosts returns when any files
+// failed to convert, holding one ConversionError per file
+type ConversionErrorList struct {
+	errs []*ConversionError
+}
+
+func (l *ConversionErrorList) Error() string {
+	return fmt.Sprintf("encountered %d errors during conversion", len(l.errs))
+}
+
+// Errors returns the errors of the files that failed, sorted by source file
+func (l *ConversionErrorList) Errors() []*ConversionError {
+	return l.errs
+}
+
+func (l *ConversionErrorList) Unwrap() []error {
+	errs := make([]error, len(l.errs))
+	for i, err := range l.errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ConversionSummary describes what ConvertPosts did, or would do in dry-run mode
+type ConversionSummary struct {
+	// SuccessCount and SkippedCount are the numbers of source files that
+	// were converted and that produced no output
+	SuccessCount int
+	SkippedCount int
+	Errors       []*ConversionError
+	Duration     time.Duration
+	// Planned lists the writes a dry run would make; it is empty otherwise
+	Planned []PlannedWrite
+	// Skipped lists the source files that produced no output
+	Skipped []string
+}
+
+// PlannedWrite is a destination file a dry run would write
+type PlannedWrite struct {
+	// SourcePath is empty for generated files such as taxonomy pages
+	SourcePath      string
+	DestinationPath string
+	// RenamedKeys maps the source front matter keys that are renamed to
+	// their new names
+	RenamedKeys map[string]string
+}
+
+// ConvertPosts converts all markdown posts in the source directory to the
+// target format. The returned error is a count of the failed files when
+// only individual files failed; the summary lists them.
+func ConvertPosts(srcDir, dstDir string, cfg *Config) (ConversionSummary, error) {
+	metrics := &conversionMetrics{}
+	manifest := &conversionManifest{}
+	summary, err := convertPosts(srcDir, dstDir, cfg, metrics, manifest)
+	if cfg.ManifestFile != "" && !cfg.DryRun && (err == nil || !cfg.WriteAtomicManifest) {
+		if manifestErr := writeManifest(cfg.ManifestFile, manifest); manifestErr != nil && err == nil {
+			err = fmt.Errorf("writing manifest: %w", manifestErr)
+		}
+	}
+	if cfg.ChecksumFile != "" && !cfg.DryRun {
+		if checksumErr := writeChecksumFile(cfg.ChecksumFile, dstDir, manifest); checksumErr != nil && err == nil {
+			err = fmt.Errorf("writing checksum file: %w", checksumErr)
+		}
+	}
+	if cfg.EnableOpenMetrics {
+		if metricsErr := writeOpenMetrics(cfg.OpenMetricsFile, summary, metrics); metricsErr != nil && err == nil {
+			err = fmt.Errorf("writing metrics: %w", metricsErr)
+		}
+	}
+	return summary, err
+}
+
+func convertPosts(srcDir, dstDir string, cfg *Config, metrics *conversionMetrics, manifest *conversionManifest) (ConversionSummary, error) {
+	start := time.Now()
+	var summary ConversionSummary
+
+	if !cfg.DryRun {
+		if err := os.MkdirAll(dstDir, 0755); err != nil {
+			return summary, fmt.Errorf("creating destination directory %s: %w", dstDir, err)
+		}
+	}
+
+	switch cfg.OutputDelimiter {
+	case "", delimiterDashes, delimiterPluses:
+	default:
+		return summary, fmt.Errorf("unsupported output delimiter %q", cfg.OutputDelimiter)
+	}
+
+	inPlace, err := sameDir(srcDir, dstDir)
+	if err != nil {
+		return summary, err
+	}
+	if !cfg.DryRun {
+		if err := checkTemporaryDirectory(cfg, dstDir); err != nil {
+			return summary, err
+		}
 	}
 
 	mc := NewMarkdownConverter(cfg)
+	if _, err := mc.loadPolicy(); err != nil {
+		return summary, err
+	}
+	if _, err := mc.loadFooter(); err != nil {
+		return summary, err
+	}
+	if _, err := mc.fmc.loadPatch(); err != nil {
+		return summary, err
+	}
 
 	var mu sync.Mutex
-	var conversionErrors []*ConversionError
+	var sortable []sortablePost
+	var progress *progressBar
+	terms := make(taxonomyTerms)
 
+	concurrency := cfg.MaxConcurrency
+	if cfg.ReverseOrderProcessing {
+		concurrency = 1
+	}
 	g, ctx := errgroup.WithContext(context.Background())
-	g.SetLimit(cfg.MaxConcurrency)
+	g.SetLimit(concurrency)
 
-	err := filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() || !strings.HasSuffix(info.Name(), cfg.FileExtension) {
-			return err
-		}
+	limiter := newWorkerLimiter(concurrency)
+	if cfg.AutoScaleWorkers {
+		scaleCtx, stopScaling := context.WithCancel(ctx)
+		defer stopScaling()
+		go autoScaleWorkers(scaleCtx, limiter, cfg)
+	}
 
+	schedule := func(path string, info os.FileInfo) error {
 		relPath, err := filepath.Rel(srcDir, path)
 		if err != nil {
 			return fmt.Errorf("getting relative path: %w", err)
@@ -150,85 +1379,523 @@ func ConvertPosts(srcDir, dstDir string, cfg *Config) error {
 		dstPath := filepath.Join(dstDir, relPath)
 
 		g.Go(func() error {
-			if err := convertFile(ctx, mc, path, dstPath); err != nil {
-				mu.Lock()
-				conversionErrors = append(conversionErrors, &ConversionError{SourceFile: path, Err: err})
-				mu.Unlock()
+			limiter.acquire()
+			if cfg.OnFileStart != nil {
+				cfg.OnFileStart(relPath)
+			}
+			fileStart := time.Now()
+			result, err := convertFile(ctx, cfg, mc, path, info, dstPath, cfg.AtomicWrites || inPlace)
+			elapsed := time.Since(fileStart)
+			limiter.release()
+			progress.increment()
+			mu.Lock()
+			defer mu.Unlock()
+			metrics.fileDurations = append(metrics.fileDurations, elapsed)
+			if err != nil {
+				summary.Errors = append(summary.Errors, &ConversionError{SourceFile: path, Err: err})
+				return nil
+			}
+			terms.add(result.frontMatter, info)
+			if len(result.paths) == 0 {
+				summary.SkippedCount++
+				summary.Skipped = append(summary.Skipped, path)
+				return nil
+			}
+			summary.SuccessCount++
+			if cfg.DryRun {
+				for _, planned := range result.paths {
+					summary.Planned = append(summary.Planned, PlannedWrite{SourcePath: path, DestinationPath: planned, RenamedKeys: result.renamedKeys})
+				}
+			} else {
+				sortable = append(sortable, sortablePost{paths: result.paths, frontMatter: result.frontMatter})
+				manifest.add(srcDir, dstDir, path, result)
 			}
 			return nil
 		})
 
 		return nil
+	}
+
+	// in-place conversions may write new files into srcDir, the progress
+	// bar needs the number of files, and reverse order needs all of them,
+	// so the walk has to finish before any of them start
+	type source struct {
+		path string
+		info os.FileInfo
+	}
+	var sources []source
+	err = walkMarkdownFiles(srcDir, cfg, func(path string, info os.FileInfo) error {
+		if inPlace || cfg.ShowProgress || cfg.ReverseOrderProcessing {
+			sources = append(sources, source{path: path, info: info})
+			return nil
+		}
+		return schedule(path, info)
 	})
+	if cfg.ReverseOrderProcessing {
+		sort.Slice(sources, func(i, j int) bool {
+			return sources[i].path > sources[j].path
+		})
+	}
+	if cfg.ShowProgress {
+		progress = newProgressBar(len(sources))
+	}
+	for i := 0; err == nil && i < len(sources); i++ {
+		err = schedule(sources[i].path, sources[i].info)
+	}
 
 	if err != nil {
-		return fmt.Errorf("walking source directory %s: %w", srcDir, err)
+		return summary, fmt.Errorf("walking source directory %s: %w", srcDir, err)
 	}
 
-	if err := g.Wait(); err != nil {
-		return err
+	err = g.Wait()
+	progress.finish()
+	if err != nil {
+		return summary, err
+	}
+
+	sort.Slice(summary.Planned, func(i, j int) bool {
+		return summary.Planned[i].DestinationPath < summary.Planned[j].DestinationPath
+	})
+	sort.Strings(summary.Skipped)
+	sort.Slice(summary.Errors, func(i, j int) bool {
+		return summary.Errors[i].SourceFile < summary.Errors[j].SourceFile
+	})
+
+	if len(summary.Errors) > 0 {
+		summary.Duration = time.Since(start)
+		return summary, &ConversionErrorList{errs: summary.Errors}
 	}
 
-	if len(conversionErrors) > 0 {
-		for _, err := range conversionErrors {
-			fmt.Printf("Error: %v\n", err)
+	if cfg.PostSortKey != "" && !cfg.DryRun {
+		renamed, err := sortPosts(dstDir, sortable, cfg)
+		manifest.rename(dstDir, renamed)
+		if err != nil {
+			return summary, fmt.Errorf("sorting posts by %s: %w", cfg.PostSortKey, err)
 		}
-		return fmt.Errorf("encountered %d errors during conversion", len(conversionErrors))
 	}
 
+	if cfg.GenerateTaxonomyFiles {
+		created, err := mc.generateTaxonomyFiles(dstDir, terms, cfg.AtomicWrites || inPlace)
+		if cfg.DryRun {
+			for _, path := range created {
+				summary.Planned = append(summary.Planned, PlannedWrite{DestinationPath: path})
+			}
+		}
+		if err != nil {
+			return summary, fmt.Errorf("generating taxonomy pages: %w", err)
+		}
+	}
+
+	summary.Duration = time.Since(start)
+	return summary, nil
+}
+
+// sameDir reports whether srcDir and dstDir refer to the same directory
+func sameDir(srcDir, dstDir string) (bool, error) {
+	srcAbs, err := filepath.Abs(srcDir)
+	if err != nil {
+		return false, fmt.Errorf("getting absolute path for %s: %w", srcDir, err)
+	}
+	dstAbs, err := filepath.Abs(dstDir)
+	if err != nil {
+		return false, fmt.Errorf("getting absolute path for %s: %w", dstDir, err)
+	}
+	return srcAbs == dstAbs, nil
+}
+
+// output is a file produced by converting a source file
+type output struct {
+	path string
+	data []byte
+}
+
+// fileResult is the outcome of converting a single source file
+type fileResult struct {
+	// paths are the files written, or that would be written in dry-run
+	// mode; they are empty when the write was skipped
+	paths       []string
+	renamedKeys map[string]string
+	// frontMatter is the converted front matter, if conversion succeeded
+	frontMatter map[string]interface{}
+	// sourceSHA256 is the checksum of the source file with ComputeChecksum
+	sourceSHA256 string
+}
+
+// ConvertFile converts the single file srcPath and writes the result to
+// dstPath, which may be srcPath itself. Every output file is written to a
+// temporary file first and renamed into place once complete. Cancelling ctx
+// stops the conversion before the source is read or any output is written.
+func ConvertFile(ctx context.Context, mc *MarkdownConverter, srcPath, dstPath string) error {
+	if _, err := convertFile(ctx, mc.cfg, mc, srcPath, nil, dstPath, true); err != nil {
+		return &ConversionError{SourceFile: srcPath, Err: err}
+	}
 	return nil
 }
 
-func convertFile(ctx context.Context, mc *MarkdownConverter, srcPath, dstPath string) error {
+// ConvertSingleFile converts srcPath to dstPath like ConvertFile and
+// reports the outcome in a summary like ConvertPosts does, including the
+// writes a dry run would make. A failed file is returned as a
+// ConversionErrorList.
+func ConvertSingleFile(ctx context.Context, mc *MarkdownConverter, srcPath, dstPath string) (ConversionSummary, error) {
+	var summary ConversionSummary
+	start := time.Now()
+	result, err := convertFile(ctx, mc.cfg, mc, srcPath, nil, dstPath, true)
+	summary.Duration = time.Since(start)
+	if err != nil {
+		summary.Errors = []*ConversionError{{SourceFile: srcPath, Err: err}}
+		return summary, &ConversionErrorList{errs: summary.Errors}
+	}
+
+	if len(result.paths) == 0 {
+		summary.SkippedCount = 1
+		summary.Skipped = []string{srcPath}
+		return summary, nil
+	}
+	summary.SuccessCount = 1
+	if mc.cfg.DryRun {
+		for _, planned := range result.paths {
+			summary.Planned = append(summary.Planned, PlannedWrite{SourcePath: srcPath, DestinationPath: planned, RenamedKeys: result.renamedKeys})
+		}
+	}
+	return summary, nil
+}
+
+// convertFile converts srcPath and writes the result under dstPath. Files
+// are written atomically when atomic is set, which in-place conversions
+// need since dstPath may be srcPath itself. srcInfo is the source file's
+// FileInfo from the walk; when nil it is read from disk if needed.
+func convertFile(ctx context.Context, cfg *Config, mc *MarkdownConverter, srcPath string, srcInfo os.FileInfo, dstPath string, atomic bool) (fileResult, error) {
+	var result fileResult
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
+		return result, ctx.Err()
 	default:
 	}
 
-	srcFile, err := os.Open(srcPath)
+	if cfg.SkipExisting {
+		if _, err := os.Stat(dstPath); err == nil {
+			cfg.logger().Info("skipping existing destination file", "file", dstPath)
+			return result, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return result, fmt.Errorf("checking destination file: %w", err)
+		}
+	}
+
+	cfg.logger().Debug("reading source file", "file", srcPath)
+	var content []byte
+	var checksum string
+	err := retryFileOp(ctx, cfg, srcPath, func() (err error) {
+		content, checksum, err = readSource(srcPath, cfg.ComputeChecksum)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("opening source file: %w", err)
+		return result, fmt.Errorf("reading source file: %w", err)
+	}
+	result.sourceSHA256 = checksum
+	if cfg.EnforceUTF8 && !utf8.Valid(content) {
+		return result, errors.New("source file is not valid UTF-8")
 	}
-	defer srcFile.Close()
 
-	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+	var outputs []output
+	p, err := mc.convert(ctx, content, srcPath, dstPath)
+	if err == nil {
+		if detectsFormat(cfg.SourceFormat) {
+			cfg.logger().Info("detected front matter format", "file", srcPath, "format", p.sourceFormat)
+		}
+		if cfg.FrontMatterDiff != nil {
+			cfg.FrontMatterDiff(srcPath, p.original, p.frontMatter)
+		}
+		result.renamedKeys = mc.fmc.renamedKeys(p.original)
+		result.frontMatter = p.frontMatter
+		outputs, err = mc.render(p, dstPath)
+	}
+	if err != nil {
+		if !cfg.IgnoreErrors {
+			return result, fmt.Errorf("converting file: %w", err)
+		}
+		cfg.logger().Warn("ignoring conversion error", "file", srcPath, "error", err)
+		fallback := content
+		if cfg.ForkOnError {
+			if converted, err := mc.convertBestEffort(content); err == nil {
+				fallback = converted
+			}
+		}
+		var buf bytes.Buffer
+		writeFallback(&buf, cfg, fallback, err)
+		outputs = []output{{path: dstPath, data: buf.Bytes()}}
+		if cfg.ForkOnError {
+			outputs = append(outputs, output{path: dstPath + ".original", data: content})
+		}
+	}
+
+	if cfg.OmitFrontMatterIfUnchanged && len(outputs) == 1 && sha256.Sum256(outputs[0].data) == sha256.Sum256(content) {
+		return result, nil
+	}
+
+	modTime := func() (time.Time, error) {
+		if srcInfo == nil {
+			info, err := os.Stat(srcPath)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("reading source file info: %w", err)
+			}
+			srcInfo = info
+		}
+		return srcInfo.ModTime(), nil
+	}
+	for _, out := range outputs {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if !cfg.DryRun {
+			if err := writeOutput(ctx, cfg, out, atomic, modTime); err != nil {
+				return result, err
+			}
+		}
+		result.paths = append(result.paths, out.path)
+	}
+	return result, nil
+}
+
+// writeOutput writes out the way every converted file is written: it backs
+// up the existing file with BackupSuffix, writes atomically through
+// TemporaryDirectory when atomic is set, retries failed writes and, with
+// PreserveModTime, sets the modification time to the one modTime returns
+func writeOutput(ctx context.Context, cfg *Config, out output, atomic bool, modTime func() (time.Time, error)) error {
+	if cfg.BackupSuffix != "" {
+		if err := backupFile(out.path, cfg.BackupSuffix); err != nil {
+			return err
+		}
+	}
+
+	write := writeFile
+	if atomic {
+		write = func(path string, data []byte, bufferSize int) error {
+			return writeFileAtomicIn(path, cfg.TemporaryDirectory, data, bufferSize)
+		}
+	}
+	err := retryFileOp(ctx, cfg, out.path, func() error {
+		return write(out.path, out.data, cfg.OutputBufferSize)
+	})
+	if err != nil {
+		return err
+	}
+
+	if cfg.PreserveModTime {
+		mtime, err := modTime()
+		if err != nil {
+			return err
+		}
+		if err := os.Chtimes(out.path, mtime, mtime); err != nil {
+			return fmt.Errorf("preserving modification time: %w", err)
+		}
+	}
+	return nil
+}
+
+// render renders the post, or each of its parts when it is split, into the
+// files to write for dstPath
+func (mc *MarkdownConverter) render(p *post, dstPath string) ([]output, error) {
+	if p.section != "" {
+		dstPath = filepath.Join(filepath.Dir(dstPath), p.section, filepath.Base(dstPath))
+	}
+
+	ext := filepath.Ext(dstPath)
+	name := strings.TrimSuffix(filepath.Base(dstPath), ext)
+	parts := mc.splitPost(p, name)
+
+	outputs := make([]output, 0, len(parts))
+	for i, part := range parts {
+		path := dstPath
+		if len(parts) > 1 {
+			path = filepath.Join(filepath.Dir(dstPath), partName(name, i+1)+ext)
+		}
+
+		if mc.cfg.TargetFormat == formatFrontMatterOnlyJSON {
+			frontMatter, err := mc.renderSeparateFrontMatter(part)
+			if err != nil {
+				return nil, err
+			}
+			outputs = append(outputs,
+				output{path: path, data: []byte(strings.TrimLeft(part.body, "\r\n"))},
+				output{path: strings.TrimSuffix(path, ext) + ".json", data: frontMatter})
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := mc.writePost(&buf, part); err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, output{path: path, data: buf.Bytes()})
+	}
+	return outputs, nil
+}
+
+// renderSeparateFrontMatter renders the front matter of p as the JSON file
+// written next to the body for formatFrontMatterOnlyJSON
+func (mc *MarkdownConverter) renderSeparateFrontMatter(p *post) ([]byte, error) {
+	frontMatter, err := mc.fmc.withDigest(p.frontMatter)
+	if err != nil {
+		return nil, fmt.Errorf("signing front matter: %w", err)
+	}
+
+	rendered, err := mc.fmc.renderFrontMatter(frontMatter, "json")
+	if err != nil {
+		return nil, fmt.Errorf("converting front matter: %w", err)
+	}
+	mc.checkLineLength(p, rendered)
+	return []byte(rendered), nil
+}
+
+// writeFile writes data to path, see writeBuffered for bufferSize. A failed
+// write removes the partial file.
+func writeFile(path string, data []byte, bufferSize int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return fmt.Errorf("creating destination directory: %w", err)
 	}
 
-	dstFile, err := os.Create(dstPath)
+	dstFile, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("creating destination file: %w", err)
 	}
 	defer dstFile.Close()
 
-	if err := mc.ConvertMarkdown(srcFile, dstFile); err != nil {
-		os.Remove(dstPath)
-		return fmt.Errorf("converting file: %w", err)
+	if err := writeBuffered(dstFile, data, bufferSize); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("writing destination file: %w", err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it over path once it is complete, so path always holds either its
+// original content or the new content. The untouched original serves as
+// the backup when anything fails before the rename.
+func writeFileAtomic(path string, data []byte, bufferSize int) error {
+	return writeFileAtomicIn(path, "", data, bufferSize)
+}
+
+// writeFileAtomicIn is writeFileAtomic with the temporary file created in
+// tmpDir, or next to path when tmpDir is empty
+func writeFileAtomicIn(path, tmpDir string, data []byte, bufferSize int) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating destination directory: %w", err)
+	}
+	if tmpDir == "" {
+		tmpDir = dir
+	}
+
+	tmpFile, err := os.CreateTemp(tmpDir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+
+	err = writeBuffered(tmpFile, data, bufferSize)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("writing destination file: %w", err)
 	}
 
 	return nil
 }
 
+// writeBuffered writes data to w through a bufio.Writer of the given size,
+// or of the bufio default size when bufferSize is 0
+func writeBuffered(w io.Writer, data []byte, bufferSize int) error {
+	var bw *bufio.Writer
+	if bufferSize > 0 {
+		bw = bufio.NewWriterSize(w, bufferSize)
+	} else {
+		bw = bufio.NewWriter(w)
+	}
+
+	if _, err := bw.Write(data); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeFallback writes the best-effort output for a file whose conversion
+// failed: the unconverted source, optionally annotated with the error
+func writeFallback(w io.Writer, cfg *Config, content []byte, convErr error) {
+	if cfg.AnnotateErrors {
+		fmt.Fprintf(w, "<!-- h2h conversion warning: %v -->\n", convErr)
+	}
+	w.Write(content)
+}
+
+// decodeFrontMatter unmarshals data in the given format, trying JSON (for
+// data that starts with "{"), YAML and then TOML when format is "detect",
+// and returns the format that was used
+func decodeFrontMatter(format string, data []byte) (map[string]interface{}, string, error) {
+	if !detectsFormat(format) {
+		var frontMatterMap map[string]interface{}
+		err := unmarshalFrontMatter(format, data, &frontMatterMap)
+		return frontMatterMap, format, err
+	}
+	return detectFrontMatter(data)
+}
+
+// DetectFormat returns the format of the front matter in data: JSON when it
+// starts with "{", otherwise whichever of YAML and TOML parses it first
+func (fmc *FrontMatterConverter) DetectFormat(data []byte) (string, error) {
+	_, format, err := detectFrontMatter(data)
+	return format, err
+}
+
+// detectFrontMatter unmarshals data in the first format that parses it
+func detectFrontMatter(data []byte) (map[string]interface{}, string, error) {
+	candidates := []string{"yaml", "toml"}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		candidates = append([]string{"json"}, candidates...)
+	}
+	for _, candidate := range candidates {
+		var frontMatterMap map[string]interface{}
+		if err := unmarshalFrontMatter(candidate, data, &frontMatterMap); err == nil {
+			return frontMatterMap, candidate, nil
+		}
+	}
+	return nil, "", errors.New("unable to detect front matter format")
+}
+
 func unmarshalFrontMatter(format string, data []byte, v interface{}) error {
 	switch format {
 	case "yaml":
 		return yaml.Unmarshal(data, v)
 	case "toml":
 		return toml.Unmarshal(data, v)
+	case "json":
+		return unmarshalJSON(data, v)
 	default:
 		return fmt.Errorf("unsupported front matter format: %s", format)
 	}
 }
 
-func marshalFrontMatter(format string, w io.Writer, v interface{}) error {
+func (fmc *FrontMatterConverter) marshalFrontMatter(format string, w io.Writer, v interface{}) error {
 	switch format {
 	case "yaml":
 		encoder := yaml.NewEncoder(w)
-		encoder.SetIndent(4)
+		if fmc.cfg.YAMLIndent > 0 {
+			encoder.SetIndent(fmc.cfg.YAMLIndent)
+		}
 		return encoder.Encode(v)
 	case "toml":
-		return toml.NewEncoder(w).Encode(v)
+		encoder := toml.NewEncoder(w)
+		encoder.Indent = strings.Repeat(" ", max(fmc.cfg.TOMLIndent, 0))
+		return encoder.Encode(v)
+	case "json":
+		return marshalJSON(w, v, fmc.cfg.JSONIndent)
 	default:
 		return fmt.Errorf("unsupported front matter format: %s", format)
 	}
@@ -236,15 +1903,89 @@ func marshalFrontMatter(format string, w io.Writer, v interface{}) error {
 
 func getHexoToHugoKeyMap() map[string]string {
 	return map[string]string{
-		"title":       "title",
-		"categories":  "categories",
-		"date":        "date",
-		"description": "description",
-		"keywords":    "keywords",
-		"permalink":   "slug",
-		"tags":        "tags",
-		"updated":     "lastmod",
+		"title":         "title",
+		"categories":    "categories",
+		"date":          "date",
+		"description":   "description",
+		"keywords":      "keywords",
+		"author":        paramsKeyPrefix + "author",
+		"permalink":     "slug",
+		"published":     "draft",
+		"redirect_from": "aliases",
+		"tags":          "tags",
+		"updated":       "lastmod",
+	}
+}
+
+// paramsKeyPrefix marks key map keys that Hugo keeps under params
+const paramsKeyPrefix = "params."
+
+// flattenParamKeys returns a copy of keyMap with the params prefix removed
+// from its keys and values
+func flattenParamKeys(keyMap map[string]string) map[string]string {
+	flattened := make(map[string]string, len(keyMap))
+	for from, to := range keyMap {
+		flattened[strings.TrimPrefix(from, paramsKeyPrefix)] = strings.TrimPrefix(to, paramsKeyPrefix)
+	}
+	return flattened
+}
+
+// hugoListKeys are the Hugo front matter keys whose values must be lists
+var hugoListKeys = map[string]bool{"aliases": true, "images": true}
+
+// chainValueTransformers returns a value transformer applying first, then
+// second when it is not nil
+func chainValueTransformers(first, second func(interface{}) interface{}) func(interface{}) interface{} {
+	if second == nil {
+		return first
+	}
+	return func(value interface{}) interface{} {
+		return second(first(value))
+	}
+}
+
+// rewriteImagePaths turns site-absolute image paths such as /img/foo.png,
+// alone or in a list, into page bundle paths such as foo.png. URLs and
+// relative paths are left as they are.
+func rewriteImagePaths(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, "/") && !strings.HasPrefix(v, "//") {
+			return path.Base(v)
+		}
+	case []interface{}:
+		rewritten := make([]interface{}, len(v))
+		for i, item := range v {
+			rewritten[i] = rewriteImagePaths(item)
+		}
+		return rewritten
+	}
+	return value
+}
+
+// wrapInList turns a single string into a one-element list
+func wrapInList(value interface{}) interface{} {
+	if s, ok := value.(string); ok {
+		return []interface{}{s}
+	}
+	return value
+}
+
+// unwrapSingleton turns a one-element list into its element
+func unwrapSingleton(value interface{}) interface{} {
+	if list, ok := value.([]interface{}); ok && len(list) == 1 {
+		return list[0]
+	}
+	return value
+}
+
+// invertBool negates boolean values, such as Hexo's published and Hugo's
+// draft, and leaves any other value as it is
+func invertBool(value interface{}) interface{} {
+	if b, ok := value.(bool); ok {
+		return !b
 	}
+	return value
 }
 
 func getHugoToHexoKeyMap() map[string]string {
@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// newFieldCipher builds an AES-256-GCM cipher from a hex-encoded 32-byte key
+func newFieldCipher(hexKey string) (cipher.AEAD, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("decoding encryption key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptFields replaces the listed string fields with base64(nonce+ciphertext)
+func encryptFields(frontMatter map[string]interface{}, fields []string, hexKey string) error {
+	aead, err := newFieldCipher(hexKey)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		value, ok := frontMatter[field]
+		if !ok {
+			continue
+		}
+		plaintext, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("encrypting field %s: value is not a string", field)
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return fmt.Errorf("encrypting field %s: %w", field, err)
+		}
+		sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+		frontMatter[field] = base64.StdEncoding.EncodeToString(sealed)
+	}
+	return nil
+}
+
+// decryptFields reverses encryptFields
+func decryptFields(frontMatter map[string]interface{}, fields []string, hexKey string) error {
+	aead, err := newFieldCipher(hexKey)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		value, ok := frontMatter[field].(string)
+		if !ok {
+			continue
+		}
+
+		sealed, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("decrypting field %s: %w", field, err)
+		}
+		if len(sealed) < aead.NonceSize() {
+			return fmt.Errorf("decrypting field %s: %w", field, errors.New("ciphertext too short"))
+		}
+
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+		if err != nil {
+			return fmt.Errorf("decrypting field %s: %w", field, err)
+		}
+		frontMatter[field] = string(plaintext)
+	}
+	return nil
+}
+
+// DecryptPosts decrypts the FrontMatterEncryptFields of every markdown file in
+// srcDir and writes the result to dstDir, leaving all other keys untouched
+func DecryptPosts(srcDir, dstDir string, cfg *Config) error {
+	if _, err := newFieldCipher(cfg.EncryptionKey); err != nil {
+		return err
+	}
+
+	fmc := NewFrontMatterConverter(cfg)
+
+	return walkMarkdownFiles(srcDir, cfg, func(path string, info os.FileInfo) error {
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return fmt.Errorf("getting relative path: %w", err)
+		}
+
+		if err := decryptFile(fmc, cfg, path, filepath.Join(dstDir, relPath)); err != nil {
+			return &ConversionError{SourceFile: path, Err: err}
+		}
+		return nil
+	})
+}
+
+func decryptFile(fmc *FrontMatterConverter, cfg *Config, srcPath, dstPath string) error {
+	content, err := os.ReadFile(srcPath)
+	if err != nil {
+		return fmt.Errorf("reading source file: %w", err)
+	}
+
+	frontMatter, body, delimiter, err := splitContent(string(content), cfg.inputDelimiter())
+	if err != nil {
+		return fmt.Errorf("parsing content: %w", err)
+	}
+
+	frontMatterMap, format, err := fmc.parseFrontMatter(frontMatter)
+	if err != nil {
+		return err
+	}
+	if err := decryptFields(frontMatterMap, cfg.FrontMatterEncryptFields, cfg.EncryptionKey); err != nil {
+		return err
+	}
+
+	outputFormat := fmc.outputFormat(format)
+	rendered, err := fmc.renderFrontMatter(frontMatterMap, outputFormat)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(wrapFrontMatter(rendered, outputFormat, fmc.outputDelimiter(delimiter)))
+	buf.WriteString(body)
+	return writeFileAtomic(dstPath, buf.Bytes(), cfg.OutputBufferSize)
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"time"
+)
+
+// dateLayouts are the layouts date values are parsed with, most specific first
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+// dateLayout returns the layout dates are written in, defaulting to
+// RFC 3339 for Hugo and YYYY-MM-DD for Hexo
+func (fmc *FrontMatterConverter) dateLayout() string {
+	if fmc.cfg.DateLayout != "" {
+		return fmc.cfg.DateLayout
+	}
+	if fmc.cfg.ConversionDirection == "hugo2hexo" {
+		return time.DateOnly
+	}
+	return time.RFC3339
+}
+
+// normalizeDates reformats the DateKeys values of frontMatter in the
+// configured layout. RFC 3339 dates stay time.Time values, so TOML writes
+// them as native datetimes and YAML leaves them unquoted; other layouts
+// become strings. Values that cannot be parsed are kept with a warning.
+func (fmc *FrontMatterConverter) normalizeDates(frontMatter map[string]interface{}) {
+	layout := fmc.dateLayout()
+	native := layout == time.RFC3339
+	for _, key := range fmc.cfg.DateKeys {
+		var t time.Time
+		switch value := frontMatter[key].(type) {
+		case time.Time:
+			t = value
+		case string:
+			parsed, ok := parseDate(value)
+			if !ok {
+				fmc.cfg.logger().Warn("keeping unparseable date", "key", key, "value", value)
+				continue
+			}
+			t = parsed
+		default:
+			continue
+		}
+		if native {
+			frontMatter[key] = t.Truncate(time.Second)
+		} else {
+			frontMatter[key] = t.Format(layout)
+		}
+	}
+}
+
+// parseDate parses s with the first matching layout in dateLayouts
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// digestKey is the front matter field DigestFrontMatter writes the HMAC to
+const digestKey = "_digest"
+
+// FrontMatterDigest returns base64(HMAC-SHA256(key, data)) where key is
+// hex-encoded and data is the compact JSON encoding of the front matter,
+// keys sorted, with any _digest field left out. Verifiers recompute it from
+// the parsed front matter and compare it with the _digest field.
+func FrontMatterDigest(frontMatter map[string]interface{}, hexKey string) (string, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return "", fmt.Errorf("decoding digest key: %w", err)
+	}
+	if len(key) == 0 {
+		return "", errors.New("digest key is empty")
+	}
+
+	unsigned := make(map[string]interface{}, len(frontMatter))
+	for k, v := range frontMatter {
+		if k != digestKey {
+			unsigned[k] = v
+		}
+	}
+	data, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", fmt.Errorf("encoding front matter for digest: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// withDigest returns a copy of the front matter with its _digest field set
+// when DigestFrontMatter is enabled, and the front matter itself otherwise
+func (fmc *FrontMatterConverter) withDigest(frontMatter map[string]interface{}) (map[string]interface{}, error) {
+	if !fmc.cfg.DigestFrontMatter {
+		return frontMatter, nil
+	}
+
+	digest, err := FrontMatterDigest(frontMatter, fmc.cfg.DigestKey)
+	if err != nil {
+		return nil, err
+	}
+	signed := make(map[string]interface{}, len(frontMatter)+1)
+	for k, v := range frontMatter {
+		signed[k] = v
+	}
+	signed[digestKey] = digest
+	return signed, nil
+}
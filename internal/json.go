@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// unmarshalJSON decodes JSON front matter, keeping integers as int64 instead of float64
+func unmarshalJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if m, ok := v.(*map[string]interface{}); ok && *m != nil {
+		*m = convertJSONNumbers(*m).(map[string]interface{})
+	}
+	return nil
+}
+
+func marshalJSON(w io.Writer, v interface{}, indent int) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	if indent > 0 {
+		encoder.SetIndent("", strings.Repeat(" ", indent))
+	}
+	return encoder.Encode(v)
+}
+
+// convertJSONNumbers replaces every json.Number below value with an int64 or float64
+func convertJSONNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		return jsonNumber(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = convertJSONNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = convertJSONNumbers(item)
+		}
+	}
+	return value
+}
+
+func jsonNumber(n json.Number) interface{} {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// splitJSONFrontMatter splits content that starts with a JSON object into
+// the object and the remaining body
+func splitJSONFrontMatter(content string) (string, string, error) {
+	decoder := json.NewDecoder(strings.NewReader(content))
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		return "", "", err
+	}
+	end := decoder.InputOffset()
+	return content[:end], content[end:], nil
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ExportKeyMap writes the key map of fmc to w as a "yaml" or "toml"
+// document of source keys and their target keys, in key order
+func ExportKeyMap(fmc *FrontMatterConverter, format string, w io.Writer) error {
+	keyMap := fmc.KeyMap()
+	if keyMap == nil {
+		keyMap = map[string]string{}
+	}
+
+	switch format {
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		if err := encoder.Encode(keyMap); err != nil {
+			return fmt.Errorf("encoding key map: %w", err)
+		}
+		return encoder.Close()
+	case "toml":
+		if err := toml.NewEncoder(w).Encode(keyMap); err != nil {
+			return fmt.Errorf("encoding key map: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported key map format: %s", format)
+	}
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var orderedListRe = regexp.MustCompile(`^\d+[.)](\s|$)`)
+
+// normalizeLineBreaks rewrites the line breaks inside paragraphs of body
+// according to strategy: "softbreak" joins the lines with a space,
+// "hardbreak" ends them with two spaces, and "none" and "" leave them
+// untouched. Breaks in code blocks and around other block elements such as
+// headings, lists and quotes are never changed.
+func normalizeLineBreaks(body, strategy string) (string, error) {
+	switch strategy {
+	case "", "none":
+		return body, nil
+	case "softbreak", "hardbreak":
+	default:
+		return "", fmt.Errorf("unsupported line break strategy: %s", strategy)
+	}
+
+	lines := strings.SplitAfter(body, "\n")
+	var sb strings.Builder
+	inFence := false
+	for i, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+		}
+		if inFence || i+1 == len(lines) || !isParagraphLine(line) || !isParagraphLine(lines[i+1]) || hasHardBreak(line) {
+			sb.WriteString(line)
+			continue
+		}
+
+		content := strings.TrimRight(line, "\r\n")
+		text := strings.TrimRight(content, " \t")
+		if strategy == "softbreak" {
+			sb.WriteString(text + " ")
+			lines[i+1] = strings.TrimLeft(lines[i+1], " \t")
+		} else {
+			sb.WriteString(text + "  " + line[len(content):])
+		}
+	}
+	return sb.String(), nil
+}
+
+// isFence reports whether line opens or closes a fenced code block
+func isFence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// isParagraphLine reports whether line is paragraph text rather than blank
+// or the start of another block element
+func isParagraphLine(line string) bool {
+	if strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ") {
+		return false
+	}
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || isFence(trimmed) || orderedListRe.MatchString(trimmed) {
+		return false
+	}
+	switch trimmed[0] {
+	case '#', '>', '|', '<', '-', '*', '+', '=', '_':
+		return false
+	}
+	return !strings.HasPrefix(trimmed, "{{")
+}
+
+// hasHardBreak reports whether line already ends with a hard line break
+func hasHardBreak(line string) bool {
+	content := strings.TrimRight(line, "\r\n")
+	return strings.HasSuffix(content, "  ") || strings.HasSuffix(content, "\\")
+}
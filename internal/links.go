@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// inlineLinkRe matches the target of an inline link or image, [text](target)
+// or ![alt](target "title"), as its second group
+var inlineLinkRe = regexp.MustCompile(`(\]\()([^)\s]+)((?:\s+"[^"]*")?\))`)
+
+// convertRelativeLinks rewrites the relative link targets in body, which
+// resolve against srcDir, so that they resolve to the same files against
+// dstDir. Absolute paths, URLs, fragments and links in fenced code blocks
+// are left alone.
+func convertRelativeLinks(body, srcDir, dstDir string) string {
+	lines := strings.SplitAfter(body, "\n")
+	inFence := false
+	for i, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		lines[i] = inlineLinkRe.ReplaceAllStringFunc(line, func(match string) string {
+			parts := inlineLinkRe.FindStringSubmatch(match)
+			return parts[1] + relinkTarget(parts[2], srcDir, dstDir) + parts[3]
+		})
+	}
+	return strings.Join(lines, "")
+}
+
+// relinkTarget returns target, a link relative to srcDir, relative to dstDir
+func relinkTarget(target, srcDir, dstDir string) string {
+	if target == "" || strings.HasPrefix(target, "/") || strings.HasPrefix(target, "#") || strings.Contains(target, ":") {
+		return target
+	}
+
+	path, suffix := target, ""
+	if i := strings.IndexAny(target, "?#"); i >= 0 {
+		path, suffix = target[:i], target[i:]
+	}
+
+	rel, err := filepath.Rel(dstDir, filepath.Join(srcDir, filepath.FromSlash(path)))
+	if err != nil {
+		return target
+	}
+	return filepath.ToSlash(rel) + suffix
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// manifestEntry records the files written for one source file. Paths are
+// relative to the source and destination directories.
+type manifestEntry struct {
+	Source       string   `json:"source"`
+	Destinations []string `json:"destinations"`
+	SourceSHA256 string   `json:"source_sha256,omitempty"`
+}
+
+// conversionManifest collects the entries of a run
+type conversionManifest struct {
+	entries []manifestEntry
+}
+
+// add records the files written for srcPath
+func (m *conversionManifest) add(srcDir, dstDir, srcPath string, result fileResult) {
+	entry := manifestEntry{Source: relSlash(srcDir, srcPath), SourceSHA256: result.sourceSHA256}
+	for _, path := range result.paths {
+		entry.Destinations = append(entry.Destinations, relSlash(dstDir, path))
+	}
+	m.entries = append(m.entries, entry)
+}
+
+// rename replaces the destinations of m that were moved, as given by the
+// new path of each moved file by its old path
+func (m *conversionManifest) rename(dstDir string, renamed map[string]string) {
+	moved := make(map[string]string, len(renamed))
+	for from, to := range renamed {
+		moved[relSlash(dstDir, from)] = relSlash(dstDir, to)
+	}
+	for _, entry := range m.entries {
+		for i, dst := range entry.Destinations {
+			if to, ok := moved[dst]; ok {
+				entry.Destinations[i] = to
+			}
+		}
+	}
+}
+
+// relSlash returns path relative to dir with forward slashes, or path
+// itself when it is not below dir
+func relSlash(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// writeManifest writes the entries of m, sorted by source, to path as JSON
+func writeManifest(path string, m *conversionManifest) error {
+	entries := append([]manifestEntry{}, m.entries...)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Source < entries[j].Source
+	})
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'), 0)
+}
+
+// writeChecksumFile writes the SHA-256 of every destination file recorded
+// in m to path in the format of sha256sum, with paths relative to dstDir,
+// so that `sha256sum --check` verifies them from dstDir. The files are
+// hashed where they are at the end of the run, so m must already reflect
+// any renames, such as those of PostSortKey.
+func writeChecksumFile(path, dstDir string, m *conversionManifest) error {
+	var destinations []string
+	for _, entry := range m.entries {
+		destinations = append(destinations, entry.Destinations...)
+	}
+	sort.Strings(destinations)
+
+	var buf bytes.Buffer
+	for _, dst := range destinations {
+		sum, err := fileSHA256(filepath.Join(dstDir, filepath.FromSlash(dst)))
+		if err != nil {
+			return fmt.Errorf("checksumming %s: %w", dst, err)
+		}
+		fmt.Fprintf(&buf, "%s  %s\n", sum, dst)
+	}
+	return writeFileAtomic(path, buf.Bytes(), 0)
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 of the file at path
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hashing %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readSource reads the file at path. With checksum set it also returns the
+// hex-encoded SHA-256 of the content, hashed as it is read.
+func readSource(path string, checksum bool) ([]byte, string, error) {
+	if !checksum {
+		content, err := os.ReadFile(path)
+		return content, "", err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	if info, err := f.Stat(); err == nil {
+		buf.Grow(int(info.Size()))
+	}
+	h := sha256.New()
+	if _, err := io.Copy(&buf, io.TeeReader(f, h)); err != nil {
+		return nil, "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	return buf.Bytes(), hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// fileDurationBuckets are the upper bounds, in seconds, of the per-file
+// conversion time histogram
+var fileDurationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// conversionMetrics collects the per-file conversion times of a run
+type conversionMetrics struct {
+	fileDurations []time.Duration
+}
+
+// writeOpenMetrics writes the summary and per-file timings of a run to path
+// in the OpenMetrics text format
+func writeOpenMetrics(path string, summary ConversionSummary, metrics *conversionMetrics) error {
+	if path == "" {
+		return errors.New("no metrics file configured")
+	}
+
+	var buf bytes.Buffer
+	writeCounter := func(name, help string, value int) {
+		fmt.Fprintf(&buf, "# TYPE %s counter\n# HELP %s %s\n%s_total %d\n", name, name, help, name, value)
+	}
+	writeCounter("h2h_files_converted", "Source files converted.", summary.SuccessCount)
+	writeCounter("h2h_files_failed", "Source files that failed to convert.", len(summary.Errors))
+	writeCounter("h2h_files_skipped", "Source files that produced no output.", summary.SkippedCount)
+
+	const histogram = "h2h_file_conversion_seconds"
+	fmt.Fprintf(&buf, "# TYPE %s histogram\n# UNIT %s seconds\n# HELP %s Time taken to convert a single file.\n", histogram, histogram, histogram)
+	var sum float64
+	counts := make([]int, len(fileDurationBuckets))
+	for _, d := range metrics.fileDurations {
+		seconds := d.Seconds()
+		sum += seconds
+		for i, bound := range fileDurationBuckets {
+			if seconds <= bound {
+				counts[i]++
+			}
+		}
+	}
+	for i, bound := range fileDurationBuckets {
+		fmt.Fprintf(&buf, "%s_bucket{le=\"%s\"} %d\n", histogram, strconv.FormatFloat(bound, 'g', -1, 64), counts[i])
+	}
+	fmt.Fprintf(&buf, "%s_bucket{le=\"+Inf\"} %d\n", histogram, len(metrics.fileDurations))
+	fmt.Fprintf(&buf, "%s_sum %s\n%s_count %d\n", histogram, strconv.FormatFloat(sum, 'g', -1, 64), histogram, len(metrics.fileDurations))
+
+	const runDuration = "h2h_conversion_duration_seconds"
+	fmt.Fprintf(&buf, "# TYPE %s gauge\n# UNIT %s seconds\n# HELP %s Wall time of the whole conversion.\n%s %s\n",
+		runDuration, runDuration, runDuration, runDuration, strconv.FormatFloat(summary.Duration.Seconds(), 'g', -1, 64))
+	buf.WriteString("# EOF\n")
+
+	return writeFileAtomic(path, buf.Bytes(), 0)
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// loadPatch decodes cfg.FrontMatterPatchFile on first use. It returns nil
+// when no patch is configured.
+func (fmc *FrontMatterConverter) loadPatch() (jsonpatch.Patch, error) {
+	fmc.patchOnce.Do(func() {
+		path := fmc.cfg.FrontMatterPatchFile
+		if path == "" {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmc.patchErr = fmt.Errorf("reading patch file: %w", err)
+			return
+		}
+		if fmc.patch, err = jsonpatch.DecodePatch(data); err != nil {
+			fmc.patchErr = fmt.Errorf("decoding patch file %s: %w", path, err)
+		}
+	})
+	return fmc.patch, fmc.patchErr
+}
+
+// applyPatch applies the configured JSON Patch to the front matter. The map
+// makes a round trip through JSON, after which values the patch left alone
+// get their original types back, so dates and floats survive unchanged.
+func (fmc *FrontMatterConverter) applyPatch(frontMatter map[string]interface{}) (map[string]interface{}, error) {
+	patch, err := fmc.loadPatch()
+	if err != nil || patch == nil {
+		return frontMatter, err
+	}
+
+	doc, err := json.Marshal(frontMatter)
+	if err != nil {
+		return nil, fmt.Errorf("encoding front matter for patch: %w", err)
+	}
+	patched, err := patch.Apply(doc)
+	if err != nil {
+		return nil, fmt.Errorf("applying patch: %w", err)
+	}
+
+	var result map[string]interface{}
+	if err := unmarshalJSON(patched, &result); err != nil {
+		return nil, fmt.Errorf("decoding patched front matter: %w", err)
+	}
+	restoreTypes(frontMatter, result)
+	return result, nil
+}
+
+// restoreTypes replaces the values of patched that are unchanged from
+// original, as far as JSON can tell, with the original values
+func restoreTypes(original, patched map[string]interface{}) {
+	for key, value := range patched {
+		before, ok := original[key]
+		if !ok {
+			continue
+		}
+		if beforeMap, ok := before.(map[string]interface{}); ok {
+			if afterMap, ok := value.(map[string]interface{}); ok {
+				restoreTypes(beforeMap, afterMap)
+				continue
+			}
+		}
+
+		beforeJSON, err := json.Marshal(before)
+		if err != nil {
+			continue
+		}
+		afterJSON, err := json.Marshal(value)
+		if err == nil && bytes.Equal(beforeJSON, afterJSON) {
+			patched[key] = before
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+// policyQuery is the Rego rule evaluated against converted front matter.
+// It must be a set of messages, one for every violation.
+const policyQuery = "data.h2h.deny"
+
+// policy is a prepared Rego policy that converted front matter must satisfy
+type policy struct {
+	query rego.PreparedEvalQuery
+}
+
+// loadPolicy reads and compiles the Rego policy in path
+func loadPolicy(path string) (*policy, error) {
+	module, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy file: %w", err)
+	}
+
+	query, err := rego.New(
+		rego.Query(policyQuery),
+		rego.Module(path, string(module)),
+	).PrepareForEval(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("compiling policy %s: %w", path, err)
+	}
+	return &policy{query: query}, nil
+}
+
+// check evaluates the policy with the front matter as input and returns an
+// error listing the violations, if any
+func (p *policy) check(ctx context.Context, frontMatter map[string]interface{}) error {
+	results, err := p.query.Eval(ctx, rego.EvalInput(frontMatter))
+	if err != nil {
+		return fmt.Errorf("evaluating policy: %w", err)
+	}
+
+	var violations []string
+	for _, result := range results {
+		for _, expr := range result.Expressions {
+			messages, ok := expr.Value.([]interface{})
+			if !ok {
+				return fmt.Errorf("evaluating policy: %s must be a set, got %T", policyQuery, expr.Value)
+			}
+			for _, message := range messages {
+				violations = append(violations, fmt.Sprint(message))
+			}
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	sort.Strings(violations)
+	return fmt.Errorf("front matter violates policy: %s", strings.Join(violations, "; "))
+}
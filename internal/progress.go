@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const progressBarWidth = 30
+
+// progressBar redraws a bar of the converted share of files on one line
+type progressBar struct {
+	w     io.Writer
+	total int
+	done  atomic.Int64
+	mu    sync.Mutex
+}
+
+// newProgressBar returns a progress bar for total files on stderr, or nil
+// when stderr is not a terminal. A nil bar ignores all calls.
+func newProgressBar(total int) *progressBar {
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progressBar{w: os.Stderr, total: total}
+}
+
+// increment counts one more finished file and redraws the bar
+func (p *progressBar) increment() {
+	if p == nil {
+		return
+	}
+	done := int(p.done.Add(1))
+
+	filled := progressBarWidth
+	if p.total > 0 {
+		filled = min(done*progressBarWidth/p.total, progressBarWidth)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "\r[%s%s] %d/%d", strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), done, p.total)
+}
+
+// finish ends the line of the bar
+func (p *progressBar) finish() {
+	if p == nil || p.done.Load() == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintln(p.w)
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// retryFileOp runs op, retrying it up to cfg.FileRetries times with
+// exponential backoff while it fails. Retries stop early once ctx is done
+// or cfg.FileTimeout has passed since the first attempt, returning the
+// last error.
+func retryFileOp(ctx context.Context, cfg *Config, path string, op func() error) error {
+	if cfg.FileTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.FileTimeout)
+		defer cancel()
+	}
+
+	err := op()
+	for attempt := 1; err != nil && attempt <= cfg.FileRetries; attempt++ {
+		delay := retryBackoff(cfg, attempt)
+		cfg.logger().Debug("retrying file operation", "file", path, "attempt", attempt, "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		err = op()
+	}
+	return err
+}
+
+// retryBackoff returns the delay before retry attempt, counting from 1:
+// cfg.RetryDelay doubled for every earlier attempt, capped at
+// cfg.MaxRetryBackoff, plus up to 10% jitter
+func retryBackoff(cfg *Config, attempt int) time.Duration {
+	delay := float64(cfg.RetryDelay) * math.Pow(2, float64(attempt-1))
+	if maxDelay := float64(cfg.MaxRetryBackoff); maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return time.Duration(delay + rand.Float64()*delay*0.1)
+}
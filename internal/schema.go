@@ -0,0 +1,163 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	jsonSchemaDraft   = "https://json-schema.org/draft/2020-12/schema"
+	maxSchemaExamples = 3
+)
+
+// Schema is a JSON Schema describing the front matter fields observed across a directory
+type Schema struct {
+	Schema     string                     `json:"$schema"`
+	Type       string                     `json:"type"`
+	Properties map[string]*SchemaProperty `json:"properties"`
+	Required   []string                   `json:"required,omitempty"`
+}
+
+// SchemaProperty describes a single observed front matter field
+type SchemaProperty struct {
+	// Type is a single JSON Schema type name, or a list of names when
+	// the field was observed with more than one type
+	Type     interface{}   `json:"type"`
+	Format   string        `json:"format,omitempty"`
+	Examples []interface{} `json:"examples,omitempty"`
+}
+
+// fieldStats accumulates observations of a single front matter field
+type fieldStats struct {
+	count    int
+	types    map[string]bool
+	format   string
+	examples []interface{}
+	seen     map[string]bool
+}
+
+// GenerateSchema scans the markdown files in srcDir and builds a JSON Schema from their front matter
+func GenerateSchema(srcDir string, cfg *Config) (*Schema, error) {
+	stats := make(map[string]*fieldStats)
+	fileCount := 0
+
+	err := walkMarkdownFiles(srcDir, cfg, func(path string, info os.FileInfo) error {
+		frontMatter, err := readFrontMatter(path, cfg.SourceFormat, cfg.inputDelimiter())
+		if err != nil {
+			return &ConversionError{SourceFile: path, Err: err}
+		}
+
+		fileCount++
+		for key, value := range frontMatter {
+			fs, ok := stats[key]
+			if !ok {
+				fs = &fieldStats{types: make(map[string]bool), seen: make(map[string]bool)}
+				stats[key] = fs
+			}
+			fs.observe(value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning source directory %s: %w", srcDir, err)
+	}
+
+	schema := &Schema{
+		Schema:     jsonSchemaDraft,
+		Type:       "object",
+		Properties: make(map[string]*SchemaProperty, len(stats)),
+	}
+	for key, fs := range stats {
+		schema.Properties[key] = fs.property()
+		if fs.count == fileCount {
+			schema.Required = append(schema.Required, key)
+		}
+	}
+	sort.Strings(schema.Required)
+
+	return schema, nil
+}
+
+// WriteSchema writes the schema to w as indented JSON
+func WriteSchema(w io.Writer, schema *Schema) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schema)
+}
+
+// readFrontMatter reads the front matter of path, split on delimiter as
+// splitContent does
+func readFrontMatter(path, format, delimiter string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+
+	frontMatter, _, _, err := splitContent(string(content), delimiter)
+	if err != nil {
+		return nil, fmt.Errorf("parsing content: %w", err)
+	}
+
+	frontMatterMap, _, err := decodeFrontMatter(format, []byte(frontMatter))
+	if err != nil {
+		return nil, fmt.Errorf("unmarshaling front matter: %w", err)
+	}
+	return frontMatterMap, nil
+}
+
+func (fs *fieldStats) observe(value interface{}) {
+	fs.count++
+
+	typeName := jsonSchemaType(value)
+	fs.types[typeName] = true
+	if _, ok := value.(time.Time); ok {
+		fs.format = "date-time"
+	}
+
+	if value == nil || typeName == "object" || len(fs.examples) >= maxSchemaExamples {
+		return
+	}
+	if sample := fmt.Sprint(value); !fs.seen[sample] {
+		fs.seen[sample] = true
+		fs.examples = append(fs.examples, value)
+	}
+}
+
+func (fs *fieldStats) property() *SchemaProperty {
+	types := make([]string, 0, len(fs.types))
+	for typeName := range fs.types {
+		types = append(types, typeName)
+	}
+	sort.Strings(types)
+
+	prop := &SchemaProperty{Format: fs.format, Examples: fs.examples}
+	if len(types) == 1 {
+		prop.Type = types[0]
+	} else {
+		prop.Type = types
+	}
+	return prop
+}
+
+func jsonSchemaType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	case []interface{}, []map[string]interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return "string"
+	}
+}
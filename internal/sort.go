@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sortRecordFile, in the destination directory, lists the files sortPosts
+// renamed, so that a later run into the same directory replaces their
+// prefixes instead of adding another one
+const sortRecordFile = ".h2h-sorted"
+
+// sortPrefixLen is the length of the prefix sortPosts gives file names
+const sortPrefixLen = len("0001-")
+
+// sortablePost holds the files written for one source file, which sortPosts
+// renames together, and the converted front matter they are sorted by
+type sortablePost struct {
+	paths       []string
+	frontMatter map[string]interface{}
+}
+
+// sortPosts renames the converted posts with a numeric prefix so that
+// directory listings follow the order of cfg.PostSortKey in their converted
+// front matter, and returns the new path of every renamed file by its old
+// path. Posts without the key, such as files written unconverted because of
+// IgnoreErrors, sort last. All files of a post, such as its parts, its JSON
+// front matter or its .original copy, get the same prefix. The ordering is
+// global, but each file keeps its own directory.
+func sortPosts(dstDir string, posts []sortablePost, cfg *Config) (map[string]string, error) {
+	sorted, err := readSortRecord(dstDir)
+	if err != nil {
+		return nil, err
+	}
+
+	key := func(p sortablePost) interface{} { return p.frontMatter[cfg.PostSortKey] }
+	sort.SliceStable(posts, func(i, j int) bool {
+		a, b := key(posts[i]), key(posts[j])
+		switch {
+		case a == nil && b == nil:
+			return posts[i].paths[0] < posts[j].paths[0]
+		case a == nil || b == nil:
+			return b == nil
+		}
+		if c := compareValues(a, b); c != 0 {
+			return c < 0
+		}
+		return posts[i].paths[0] < posts[j].paths[0]
+	})
+
+	renamed := make(map[string]string)
+	var record []string
+	for i, p := range posts {
+		for _, path := range p.paths {
+			name := filepath.Base(path)
+			if sorted[relSlash(dstDir, path)] && len(name) > sortPrefixLen {
+				name = name[sortPrefixLen:]
+			}
+			sortedPath := filepath.Join(filepath.Dir(path), fmt.Sprintf("%04d-%s", i+1, name))
+			if err := os.Rename(path, sortedPath); err != nil {
+				return renamed, fmt.Errorf("renaming %s: %w", path, err)
+			}
+			renamed[path] = sortedPath
+			record = append(record, relSlash(dstDir, sortedPath))
+		}
+	}
+
+	sort.Strings(record)
+	data := []byte(strings.Join(record, "\n") + "\n")
+	if err := writeFileAtomic(filepath.Join(dstDir, sortRecordFile), data, 0); err != nil {
+		return renamed, fmt.Errorf("writing %s: %w", sortRecordFile, err)
+	}
+	return renamed, nil
+}
+
+// readSortRecord returns the set of files, relative to dstDir, that an
+// earlier run of sortPosts renamed
+func readSortRecord(dstDir string) (map[string]bool, error) {
+	data, err := os.ReadFile(filepath.Join(dstDir, sortRecordFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", sortRecordFile, err)
+	}
+
+	sorted := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			sorted[line] = true
+		}
+	}
+	return sorted, nil
+}
+
+// compareValues orders two front matter values, comparing dates and
+// numbers by value and anything else by its string form
+func compareValues(a, b interface{}) int {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Compare(tb)
+		}
+	}
+	if na, ok := toFloat(a); ok {
+		if nb, ok := toFloat(b); ok {
+			switch {
+			case na < nb:
+				return -1
+			case na > nb:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	sa, sb := fmt.Sprint(a), fmt.Sprint(b)
+	switch {
+	case sa < sb:
+		return -1
+	case sa > sb:
+		return 1
+	}
+	return 0
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitPost splits a post whose body is longer than cfg.SplitAtLines at the
+// headings nearest to each multiple of the limit. Every part gets a copy of
+// the front matter with part, prev and next fields, where prev and next name
+// the sibling parts as <name>-part-<N>. Posts that are short enough, or have
+// no suitable heading, are returned unchanged.
+func (mc *MarkdownConverter) splitPost(p *post, name string) []*post {
+	limit := mc.cfg.SplitAtLines
+	if !mc.cfg.SplitLongPosts || limit <= 0 {
+		return []*post{p}
+	}
+
+	lines := strings.SplitAfter(p.body, "\n")
+	headings := headingLines(lines)
+
+	var bounds []int
+	start := 0
+	for len(lines)-start > limit {
+		target := start + limit
+		best := -1
+		for _, h := range headings {
+			if h <= start {
+				continue
+			}
+			if best == -1 || abs(h-target) < abs(best-target) {
+				best = h
+			}
+		}
+		if best == -1 {
+			break
+		}
+		bounds = append(bounds, best)
+		start = best
+	}
+	if len(bounds) == 0 {
+		return []*post{p}
+	}
+
+	bounds = append([]int{0}, append(bounds, len(lines))...)
+	parts := make([]*post, 0, len(bounds)-1)
+	for i := 0; i+1 < len(bounds); i++ {
+		part := *p
+		part.body = strings.Join(lines[bounds[i]:bounds[i+1]], "")
+		part.frontMatter = make(map[string]interface{}, len(p.frontMatter)+3)
+		for key, value := range p.frontMatter {
+			part.frontMatter[key] = value
+		}
+
+		n := i + 1
+		part.frontMatter["part"] = n
+		if n > 1 {
+			part.frontMatter["prev"] = partName(name, n-1)
+		}
+		if n < len(bounds)-1 {
+			part.frontMatter["next"] = partName(name, n+1)
+		}
+		parts = append(parts, &part)
+	}
+	return parts
+}
+
+// headingLines returns the indexes of ATX heading lines outside fenced code blocks
+func headingLines(lines []string) []int {
+	var headings []int
+	inFence := false
+	for i, line := range lines {
+		if isFence(line) {
+			inFence = !inFence
+			continue
+		}
+		if !inFence && strings.HasPrefix(line, "#") && strings.HasPrefix(strings.TrimLeft(line, "#"), " ") {
+			headings = append(headings, i)
+		}
+	}
+	return headings
+}
+
+func partName(name string, n int) string {
+	return fmt.Sprintf("%s-part-%d", name, n)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
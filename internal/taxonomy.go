@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// taxonomyKeys are the front matter keys GenerateTaxonomyFiles creates list pages for
+var taxonomyKeys = []string{"tags", "categories"}
+
+// taxonomyTerms collects the unique terms of each taxonomy across posts,
+// along with the newest modification time of the posts that use each term
+type taxonomyTerms map[string]map[string]time.Time
+
+// add records the taxonomy terms used by the front matter of the source
+// file described by info
+func (t taxonomyTerms) add(frontMatter map[string]interface{}, info os.FileInfo) {
+	var modTime time.Time
+	if info != nil {
+		modTime = info.ModTime()
+	}
+
+	for _, taxonomy := range taxonomyKeys {
+		var terms []interface{}
+		switch value := frontMatter[taxonomy].(type) {
+		case string:
+			terms = []interface{}{value}
+		case []interface{}:
+			terms = value
+		}
+
+		for _, term := range terms {
+			name := strings.TrimSpace(fmt.Sprint(term))
+			if name == "" {
+				continue
+			}
+			if t[taxonomy] == nil {
+				t[taxonomy] = make(map[string]time.Time)
+			}
+			if modTime.After(t[taxonomy][name]) {
+				t[taxonomy][name] = modTime
+			}
+		}
+	}
+}
+
+// generateTaxonomyFiles creates <dstDir>/<taxonomy>/<term>/_index.md for
+// every term that has none yet, and returns the paths it created. The
+// pages are written like converted posts, atomically when atomic is set,
+// and take the newest modification time of the posts using the term with
+// PreserveModTime. In dry-run mode the paths are returned without writing
+// anything.
+func (mc *MarkdownConverter) generateTaxonomyFiles(dstDir string, terms taxonomyTerms, atomic bool) ([]string, error) {
+	var created []string
+	for _, taxonomy := range taxonomyKeys {
+		names := make([]string, 0, len(terms[taxonomy]))
+		for name := range terms[taxonomy] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			path := filepath.Join(dstDir, taxonomy, taxonomyTermDir(name), "_index.md")
+			if _, err := os.Stat(path); err == nil {
+				continue
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return created, fmt.Errorf("checking taxonomy page %s: %w", path, err)
+			}
+
+			outputs, err := mc.renderTaxonomyPage(path, name)
+			if err != nil {
+				return created, err
+			}
+			modTime := terms[taxonomy][name]
+			for _, out := range outputs {
+				if !mc.cfg.DryRun {
+					err := writeOutput(context.Background(), mc.cfg, out, atomic, func() (time.Time, error) {
+						return modTime, nil
+					})
+					if err != nil {
+						return created, err
+					}
+				}
+				created = append(created, out.path)
+			}
+		}
+	}
+	return created, nil
+}
+
+// renderTaxonomyPage renders the list page of the term name the way posts
+// are rendered for the target format: front matter in _index.md, or the
+// body in _index.md and the front matter in _index.json for
+// formatFrontMatterOnlyJSON
+func (mc *MarkdownConverter) renderTaxonomyPage(path, name string) ([]output, error) {
+	frontMatter := map[string]interface{}{"title": name}
+	if mc.cfg.TargetFormat == formatFrontMatterOnlyJSON {
+		rendered, err := mc.fmc.renderFrontMatter(frontMatter, "json")
+		if err != nil {
+			return nil, err
+		}
+		return []output{
+			{path: path, data: nil},
+			{path: strings.TrimSuffix(path, filepath.Ext(path)) + ".json", data: []byte(rendered)},
+		}, nil
+	}
+
+	format := mc.fmc.targetFormat
+	rendered, err := mc.fmc.renderFrontMatter(frontMatter, format)
+	if err != nil {
+		return nil, err
+	}
+	return []output{{path: path, data: []byte(wrapFrontMatter(rendered, format, mc.fmc.outputDelimiter("")) + "\n")}}, nil
+}
+
+// taxonomyTermDir returns the directory name Hugo uses for a taxonomy term
+func taxonomyTermDir(name string) string {
+	name = strings.NewReplacer("/", "-", "\\", "-").Replace(strings.ToLower(name))
+	return strings.Join(strings.Fields(name), "-")
+}
@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkTemporaryDirectory verifies that cfg.TemporaryDirectory is a
+// directory and warns when it is on another filesystem than dstDir, where
+// renaming temporary files over destination files is not atomic and may
+// fail altogether
+func checkTemporaryDirectory(cfg *Config, dstDir string) error {
+	if cfg.TemporaryDirectory == "" {
+		return nil
+	}
+	info, err := os.Stat(cfg.TemporaryDirectory)
+	if err != nil {
+		return fmt.Errorf("checking temporary directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("temporary directory %s is not a directory", cfg.TemporaryDirectory)
+	}
+
+	same, err := sameFilesystem(cfg.TemporaryDirectory, dstDir)
+	if err != nil {
+		return fmt.Errorf("checking temporary directory: %w", err)
+	}
+	if !same {
+		cfg.logger().Warn("temporary directory is on another filesystem than the destination directory, atomic writes may fail",
+			"temporary_directory", cfg.TemporaryDirectory, "dir", dstDir)
+	}
+	return nil
+}
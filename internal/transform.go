@@ -0,0 +1,484 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yuin/goldmark"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+var htmlTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// transformValues applies the configured value transformations to a converted front matter map
+func (fmc *FrontMatterConverter) transformValues(frontMatter map[string]interface{}) error {
+	if err := normalizeNewlines(frontMatter, fmc.cfg.NewlineNormalization); err != nil {
+		return err
+	}
+
+	if fmc.cfg.EmptyStringAsNull {
+		emptyStringsToNull(frontMatter)
+	}
+
+	if fmc.cfg.NormalizeDates {
+		fmc.normalizeDates(frontMatter)
+	}
+
+	if fmc.cfg.StripMarkdownFormatting {
+		for _, key := range fmc.cfg.MarkdownFields {
+			if value, ok := frontMatter[key].(string); ok {
+				plain, err := stripMarkdown(value)
+				if err != nil {
+					return fmt.Errorf("stripping markdown from %s: %w", key, err)
+				}
+				frontMatter[key] = plain
+			}
+		}
+	}
+
+	if limit := fmc.cfg.TruncateDescription; limit > 0 {
+		if description, ok := frontMatter["description"].(string); ok {
+			frontMatter["description"] = truncateAtWord(description, limit)
+		}
+	}
+
+	if fmc.cfg.SanitizeSlug {
+		for _, key := range []string{"slug", "permalink"} {
+			if slug, ok := frontMatter[key].(string); ok {
+				frontMatter[key] = Slugify(slug)
+			}
+		}
+	}
+
+	fmc.slugFromTitle(frontMatter)
+
+	if precision := fmc.cfg.FrontMatterMarshalPrecision; precision > 0 {
+		roundFloats(frontMatter, precision)
+	}
+
+	if fmc.cfg.NormalizeTags {
+		if err := normalizeTaxonomies(frontMatter, fmc.cfg.TagCase); err != nil {
+			return err
+		}
+	}
+
+	if err := applyTypeHints(frontMatter, fmc.cfg.FrontMatterTypeHints); err != nil {
+		return err
+	}
+
+	if err := fmc.requireFields(frontMatter); err != nil {
+		return err
+	}
+
+	if len(fmc.cfg.FrontMatterEncryptFields) > 0 {
+		if err := encryptFields(frontMatter, fmc.cfg.FrontMatterEncryptFields, fmc.cfg.EncryptionKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stripMarkdown renders s as Markdown and returns the text of the resulting
+// HTML with tags removed and whitespace collapsed
+func stripMarkdown(s string) (string, error) {
+	var buf bytes.Buffer
+	if err := goldmark.Convert([]byte(s), &buf); err != nil {
+		return "", err
+	}
+	text := html.UnescapeString(htmlTagRe.ReplaceAllString(buf.String(), ""))
+	return strings.Join(strings.Fields(text), " "), nil
+}
+
+// requireFields fills the RequireFields missing from frontMatter with
+// FrontMatterNullChar, or fails listing them when no sentinel is set
+func (fmc *FrontMatterConverter) requireFields(frontMatter map[string]interface{}) error {
+	var missing []string
+	for _, key := range fmc.cfg.RequireFields {
+		if _, ok := frontMatter[key]; ok {
+			continue
+		}
+		if fmc.cfg.FrontMatterNullChar != "" {
+			frontMatter[key] = fmc.cfg.FrontMatterNullChar
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required fields: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// truncateAtWord shortens s to at most limit characters, cutting at the last
+// word boundary before the limit and appending " ..."
+func truncateAtWord(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+
+	cut := runes[:limit]
+	if !unicode.IsSpace(runes[limit]) {
+		for i := len(cut) - 1; i > 0; i-- {
+			if unicode.IsSpace(cut[i]) {
+				cut = cut[:i]
+				break
+			}
+		}
+	}
+
+	return strings.TrimRightFunc(string(cut), unicode.IsSpace) + " ..."
+}
+
+// slugFromTitle derives a missing slug from the title when cfg.SlugFromTitle is set
+func (fmc *FrontMatterConverter) slugFromTitle(frontMatter map[string]interface{}) {
+	if !fmc.cfg.SlugFromTitle {
+		return
+	}
+
+	key := fmc.slugKey()
+	if _, ok := frontMatter[key]; ok {
+		return
+	}
+	if title, ok := frontMatter["title"].(string); ok {
+		if slug := Slugify(title); slug != "" {
+			frontMatter[key] = slug
+		}
+	}
+}
+
+// slugKey returns the name of the slug field in the target front matter
+func (fmc *FrontMatterConverter) slugKey() string {
+	if fmc.cfg.ConversionDirection == "hugo2hexo" {
+		return "permalink"
+	}
+	return "slug"
+}
+
+// Slugify normalises s, typically a post title, to a lowercase,
+// hyphen-separated URL slug, dropping accents and any character that is not
+// a letter, digit or hyphen. SanitizeSlug and SlugFromTitle use it.
+func Slugify(s string) string {
+	var sb strings.Builder
+	lastHyphen := true
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+			lastHyphen = false
+		case r == '-' || unicode.IsSpace(r):
+			if !lastHyphen {
+				sb.WriteRune('-')
+				lastHyphen = true
+			}
+		}
+	}
+	return strings.TrimRight(sb.String(), "-")
+}
+
+// normalizeTaxonomies normalizes the list values of the taxonomy keys
+// categories, tags and keywords with normalizeTags
+func normalizeTaxonomies(frontMatter map[string]interface{}, tagCase string) error {
+	var caser func(string) string
+	switch tagCase {
+	case "", "lower":
+		caser = strings.ToLower
+	case "upper":
+		caser = strings.ToUpper
+	case "title":
+		caser = cases.Title(language.Und).String
+	default:
+		return fmt.Errorf("unsupported tag case: %s", tagCase)
+	}
+
+	for _, key := range []string{"categories", "tags", "keywords"} {
+		if values, ok := frontMatter[key].([]interface{}); ok {
+			frontMatter[key] = normalizeTags(values, caser)
+		}
+	}
+	return nil
+}
+
+// normalizeTags trims the string values, drops empty ones, applies caser
+// and removes case-insensitive duplicates, keeping the first occurrence.
+// Values that are not strings are kept as they are.
+func normalizeTags(values []interface{}, caser func(string) string) []interface{} {
+	normalized := make([]interface{}, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			normalized = append(normalized, value)
+			continue
+		}
+		s = strings.TrimSpace(s)
+		if s == "" || seen[strings.ToLower(s)] {
+			continue
+		}
+		seen[strings.ToLower(s)] = true
+		normalized = append(normalized, caser(s))
+	}
+	return normalized
+}
+
+// applyTypeHints converts the values of the keys in hints to the type named
+// by each hint: "string", "int", "float", "bool" or "[]string". Missing and
+// null values are left alone.
+func applyTypeHints(frontMatter map[string]interface{}, hints map[string]string) error {
+	for key, hint := range hints {
+		value, ok := frontMatter[key]
+		if !ok || value == nil {
+			continue
+		}
+		coerced, err := coerceValue(value, hint)
+		if err != nil {
+			return fmt.Errorf("coercing %s to %s: %w", key, hint, err)
+		}
+		frontMatter[key] = coerced
+	}
+	return nil
+}
+
+// coerceValue converts a scalar front matter value to the named type, or
+// any value to a list of strings for "[]string"
+func coerceValue(value interface{}, typ string) (interface{}, error) {
+	if typ == "[]string" {
+		list, ok := value.([]interface{})
+		if !ok {
+			list = []interface{}{value}
+		}
+		coerced := make([]interface{}, len(list))
+		for i, item := range list {
+			s, err := coerceValue(item, "string")
+			if err != nil {
+				return nil, err
+			}
+			coerced[i] = s
+		}
+		return coerced, nil
+	}
+
+	var s string
+	switch v := value.(type) {
+	case map[string]interface{}, []interface{}:
+		return nil, fmt.Errorf("cannot convert %T", value)
+	case time.Time:
+		s = v.Format(time.RFC3339)
+	default:
+		s = strings.TrimSpace(fmt.Sprint(v))
+	}
+
+	switch typ {
+	case "string":
+		return s, nil
+	case "int":
+		if f, ok := value.(float64); ok && f == math.Trunc(f) {
+			return int64(f), nil
+		}
+		return strconv.ParseInt(s, 10, 64)
+	case "float":
+		return strconv.ParseFloat(s, 64)
+	case "bool":
+		return strconv.ParseBool(s)
+	}
+	return nil, errors.New("unsupported type hint")
+}
+
+// ParseFrontMatterValue parses s as a front matter value: true and false
+// become booleans, integers int64, RFC 3339 timestamps and YYYY-MM-DD dates
+// time.Time, and anything else stays a string
+func ParseFrontMatterValue(s string) interface{} {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return s
+}
+
+// keywordsFromTags copies the tags of frontMatter to keywords when it has
+// none, or with merge appends the tags that keywords lacks
+func keywordsFromTags(frontMatter map[string]interface{}, merge bool) {
+	tags, ok := frontMatter["tags"]
+	if !ok {
+		return
+	}
+	keywords, ok := frontMatter["keywords"]
+	if !ok {
+		frontMatter["keywords"] = copyValue(tags)
+		return
+	}
+	if !merge {
+		return
+	}
+
+	merged := slices.Clone(asList(keywords))
+	for _, tag := range asList(tags) {
+		if !slices.Contains(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	frontMatter["keywords"] = merged
+}
+
+// asList returns value if it is a list, or a list holding value otherwise
+func asList(value interface{}) []interface{} {
+	if list, ok := value.([]interface{}); ok {
+		return list
+	}
+	return []interface{}{value}
+}
+
+// schemaOrgKey is the front matter key GenerateSchemaOrgFields writes to
+const schemaOrgKey = "schema_org"
+
+// schemaOrgFields returns the Schema.org BlogPosting fields for the front
+// matter, taking the author from author or params.author. Fields whose
+// source keys are missing are left out.
+func schemaOrgFields(frontMatter map[string]interface{}) map[string]interface{} {
+	fields := map[string]interface{}{"@type": "BlogPosting"}
+	if title, ok := frontMatter["title"]; ok {
+		fields["headline"] = title
+	}
+	if date, ok := frontMatter["date"]; ok {
+		fields["datePublished"] = date
+	}
+
+	author, ok := frontMatter["author"]
+	if params, isMap := frontMatter["params"].(map[string]interface{}); !ok && isMap {
+		author, ok = params["author"]
+	}
+	if ok {
+		fields["author"] = map[string]interface{}{"@type": "Person", "name": copyValue(author)}
+	}
+	return fields
+}
+
+// roundFloats rounds every float64 inside the maps and slices of value to
+// precision decimal places
+func roundFloats(value interface{}, precision int) {
+	scale := math.Pow(10, float64(precision))
+	round := func(f float64) float64 { return math.Round(f*scale) / scale }
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if f, ok := item.(float64); ok {
+				v[key] = round(f)
+			} else {
+				roundFloats(item, precision)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if f, ok := item.(float64); ok {
+				v[i] = round(f)
+			} else {
+				roundFloats(item, precision)
+			}
+		}
+	}
+}
+
+// normalizeNewlines rewrites the line endings of every string value below
+// value to "lf" or "crlf"; "none" and "" leave them untouched
+func normalizeNewlines(value interface{}, mode string) error {
+	var replacer *strings.Replacer
+	switch mode {
+	case "", "none":
+		return nil
+	case "lf":
+		replacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+	case "crlf":
+		replacer = strings.NewReplacer("\r\n", "\r\n", "\r", "\r\n", "\n", "\r\n")
+	default:
+		return fmt.Errorf("unsupported newline normalization: %s", mode)
+	}
+
+	mapStrings(value, replacer.Replace)
+	return nil
+}
+
+// emptyStringsToNull replaces empty string map values below value with nil.
+// Sequence elements are left alone since TOML arrays cannot hold nulls.
+func emptyStringsToNull(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if item == "" {
+				v[key] = nil
+			} else {
+				emptyStringsToNull(item)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			emptyStringsToNull(item)
+		}
+	}
+}
+
+// copyValue deep-copies the maps and slices of a front matter value so the
+// converted map can be modified without affecting the source map
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	}
+	return value
+}
+
+// mapStrings replaces every string inside the maps and slices of value with fn(s)
+func mapStrings(value interface{}, fn func(string) string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if s, ok := item.(string); ok {
+				v[key] = fn(s)
+			} else {
+				mapStrings(item, fn)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if s, ok := item.(string); ok {
+				v[i] = fn(s)
+			} else {
+				mapStrings(item, fn)
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package internal
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// typeHints are the type names FrontMatterTypeHints accepts
+var typeHints = []string{"string", "int", "float", "bool", "[]string"}
+
+// Validate checks the configuration before any file is converted and
+// returns an error listing every invalid field. A MaxConcurrency below 1 is
+// not an error; it is raised to 1 with a warning.
+func (cfg *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch cfg.SourceFormat {
+	case "yaml", "toml", "json", formatDetect, formatAuto:
+	default:
+		invalid("unsupported source format: %q", cfg.SourceFormat)
+	}
+	switch cfg.TargetFormat {
+	case "yaml", "toml", "json", formatFrontMatterOnlyJSON:
+	default:
+		invalid("unsupported target format: %q", cfg.TargetFormat)
+	}
+	switch cfg.ConversionDirection {
+	case "hexo2hugo", "hugo2hexo", directionPassthrough:
+	default:
+		invalid("unsupported conversion direction: %q", cfg.ConversionDirection)
+	}
+	switch cfg.OutputDelimiter {
+	case "", delimiterDashes, delimiterPluses:
+	default:
+		invalid("unsupported output delimiter: %q", cfg.OutputDelimiter)
+	}
+	switch cfg.NewlineNormalization {
+	case "", "none", "lf", "crlf":
+	default:
+		invalid("unsupported newline normalization: %q", cfg.NewlineNormalization)
+	}
+	switch cfg.LineBreakStrategy {
+	case "", "none", "softbreak", "hardbreak":
+	default:
+		invalid("unsupported line break strategy: %q", cfg.LineBreakStrategy)
+	}
+	switch cfg.TagCase {
+	case "", "lower", "upper", "title":
+	default:
+		invalid("unsupported tag case: %q", cfg.TagCase)
+	}
+	for _, key := range slices.Sorted(maps.Keys(cfg.FrontMatterStyle)) {
+		if style := cfg.FrontMatterStyle[key]; style != "block" && style != "flow" {
+			invalid("unsupported style %q for field %s", style, key)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(cfg.FrontMatterTypeHints)) {
+		if hint := cfg.FrontMatterTypeHints[key]; !slices.Contains(typeHints, hint) {
+			invalid("unsupported type hint %q for field %s", hint, key)
+		}
+	}
+
+	for _, pattern := range slices.Concat(cfg.IncludeGlobs, cfg.ExcludeGlobs) {
+		if !doublestar.ValidatePattern(pattern) {
+			invalid("invalid glob pattern %q: %w", pattern, doublestar.ErrBadPattern)
+		}
+	}
+	if len(cfg.FrontMatterEncryptFields) > 0 {
+		if _, err := newFieldCipher(cfg.EncryptionKey); err != nil {
+			invalid("invalid EncryptionKey: %w", err)
+		}
+	}
+	if cfg.DigestFrontMatter {
+		if key, err := hex.DecodeString(cfg.DigestKey); err != nil {
+			invalid("invalid DigestKey: %w", err)
+		} else if len(key) == 0 {
+			invalid("DigestKey must be set when DigestFrontMatter is")
+		}
+	}
+	if cfg.EnableOpenMetrics && cfg.OpenMetricsFile == "" {
+		invalid("OpenMetricsFile must be set when EnableOpenMetrics is")
+	}
+
+	switch cfg.YAMLIndent {
+	case 0, 2, 4:
+	default:
+		invalid("YAMLIndent must be 2 or 4, or 0 for the default, got %d", cfg.YAMLIndent)
+	}
+	for _, field := range []struct {
+		name  string
+		value int
+	}{
+		{"JSONIndent", cfg.JSONIndent},
+		{"TOMLIndent", cfg.TOMLIndent},
+		{"FrontMatterFieldWidth", cfg.FrontMatterFieldWidth},
+		{"FrontMatterMarshalPrecision", cfg.FrontMatterMarshalPrecision},
+		{"TruncateDescription", cfg.TruncateDescription},
+		{"MaxLineLength", cfg.MaxLineLength},
+		{"SplitAtLines", cfg.SplitAtLines},
+		{"MemoryLimitMB", cfg.MemoryLimitMB},
+		{"ReadingSpeedWPM", cfg.ReadingSpeedWPM},
+		{"OutputBufferSize", cfg.OutputBufferSize},
+		{"FileRetries", cfg.FileRetries},
+		{"LogMaxSizeMB", cfg.LogMaxSizeMB},
+		{"LogMaxBackups", cfg.LogMaxBackups},
+	} {
+		if field.value < 0 {
+			invalid("%s must not be negative, got %d", field.name, field.value)
+		}
+	}
+
+	if cfg.MaxConcurrency < 1 {
+		cfg.logger().Warn("MaxConcurrency below 1, using 1", "max_concurrency", cfg.MaxConcurrency)
+		cfg.MaxConcurrency = 1
+	}
+	return errors.Join(errs...)
+}
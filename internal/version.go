@@ -0,0 +1,18 @@
+package internal
+
+import "runtime/debug"
+
+// versionKey and directionKey are the fields InjectH2HVersion adds
+const (
+	versionKey   = "_h2h_version"
+	directionKey = "_h2h_direction"
+)
+
+// h2hVersion returns the module version h2h was built as, or "(devel)" for
+// builds outside of a released module
+func h2hVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
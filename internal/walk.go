@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// walkMarkdownFiles calls fn for every file below srcDir with the configured
+// extension, skipping hidden files and directories when cfg.SkipHiddenFiles is set
+// and files filtered out by cfg.IncludeGlobs and cfg.ExcludeGlobs
+func walkMarkdownFiles(srcDir string, cfg *Config, fn func(path string, info os.FileInfo) error) error {
+	if err := validateGlobs(cfg); err != nil {
+		return err
+	}
+
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if cfg.SkipHiddenFiles && path != srcDir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return fmt.Errorf("getting relative path: %w", err)
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if info.IsDir() {
+			if path != srcDir && matchesAny(cfg.ExcludeGlobs, relPath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !selectsFile(cfg, relPath) {
+			return nil
+		}
+		return fn(path, info)
+	})
+}
+
+// selectsFile reports whether the file at the slash-separated relPath has
+// the configured extension and passes the include and exclude globs
+func selectsFile(cfg *Config, relPath string) bool {
+	if !strings.HasSuffix(relPath, cfg.FileExtension) || matchesAny(cfg.ExcludeGlobs, relPath) {
+		return false
+	}
+	return len(cfg.IncludeGlobs) == 0 || matchesAny(cfg.IncludeGlobs, relPath)
+}
+
+// validateGlobs checks the include and exclude glob patterns
+func validateGlobs(cfg *Config) error {
+	for _, pattern := range append(append([]string(nil), cfg.IncludeGlobs...), cfg.ExcludeGlobs...) {
+		if !doublestar.ValidatePattern(pattern) {
+			return fmt.Errorf("invalid glob pattern %q: %w", pattern, doublestar.ErrBadPattern)
+		}
+	}
+	return nil
+}
+
+// matchesAny reports whether the slash-separated path matches any of the glob patterns
+func matchesAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if doublestar.MatchUnvalidated(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchAndConvert converts the markdown files below srcDir into dstDir as
+// they are created or written, until ctx is cancelled. Events for the same
+// file within cfg.WatchDebounce of each other trigger a single conversion.
+// With cfg.WatchDelete, deleting or renaming a source file removes its
+// destination file. Conversion errors are logged rather than returned.
+func WatchAndConvert(srcDir, dstDir string, cfg *Config, ctx context.Context) error {
+	inPlace, err := sameDir(srcDir, dstDir)
+	if err != nil {
+		return err
+	}
+	if inPlace {
+		return errors.New("watching requires a destination directory other than the source directory")
+	}
+
+	if err := validateGlobs(cfg); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return fmt.Errorf("creating destination directory %s: %w", dstDir, err)
+	}
+	if err := checkTemporaryDirectory(cfg, dstDir); err != nil {
+		return err
+	}
+
+	mc := NewMarkdownConverter(cfg)
+	if _, err := mc.loadPolicy(); err != nil {
+		return err
+	}
+	if _, err := mc.loadFooter(); err != nil {
+		return err
+	}
+	if _, err := mc.fmc.loadPatch(); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watchDirs(watcher, srcDir, srcDir, cfg); err != nil {
+		return err
+	}
+
+	logger := cfg.logger()
+	pending := make(map[string]*debounce)
+	ready := make(chan *debounce)
+	defer func() {
+		for _, d := range pending {
+			d.timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Error("watching source directory", "error", err)
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(srcDir, event.Name)
+			if err != nil {
+				continue
+			}
+
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watchDirs(watcher, srcDir, event.Name, cfg); err != nil {
+						logger.Error("watching new directory", "dir", event.Name, "error", err)
+					}
+					continue
+				}
+			}
+			if !watchesFile(cfg, relPath) {
+				continue
+			}
+
+			switch {
+			case event.Has(fsnotify.Create) || event.Has(fsnotify.Write):
+				// a timer that has already fired may not have been received
+				// from ready yet, so it is replaced rather than reset
+				if d, ok := pending[event.Name]; ok && d.timer.Stop() {
+					d.timer.Reset(cfg.WatchDebounce)
+					continue
+				}
+				d := &debounce{path: event.Name}
+				d.timer = time.AfterFunc(cfg.WatchDebounce, func() {
+					select {
+					case ready <- d:
+					case <-ctx.Done():
+					}
+				})
+				pending[event.Name] = d
+
+			case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+				if d, ok := pending[event.Name]; ok {
+					d.timer.Stop()
+					delete(pending, event.Name)
+				}
+				if !cfg.WatchDelete {
+					continue
+				}
+				dstPath := filepath.Join(dstDir, relPath)
+				if err := os.Remove(dstPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					logger.Error("removing destination file", "file", dstPath, "error", err)
+				} else if err == nil {
+					logger.Info("removed destination file", "file", dstPath)
+				}
+			}
+
+		case d := <-ready:
+			if pending[d.path] != d {
+				// replaced by a later event, or its file was removed
+				continue
+			}
+			delete(pending, d.path)
+			path := d.path
+			relPath, err := filepath.Rel(srcDir, path)
+			if err != nil {
+				continue
+			}
+			if cfg.OnFileStart != nil {
+				cfg.OnFileStart(relPath)
+			}
+			if _, err := convertFile(ctx, cfg, mc, path, nil, filepath.Join(dstDir, relPath), cfg.AtomicWrites); err != nil {
+				logger.Error("converting file", "file", path, "error", err)
+			} else {
+				logger.Info("converted file", "file", path)
+			}
+		}
+	}
+}
+
+// debounce is a conversion of path scheduled by its timer. It is stale
+// once it is no longer the pending conversion of its path.
+type debounce struct {
+	path  string
+	timer *time.Timer
+}
+
+// watchDirs adds dir and every directory below it to the watcher, skipping
+// hidden and excluded directories like walkMarkdownFiles does
+func watchDirs(watcher *fsnotify.Watcher, srcDir, dir string, cfg *Config) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != srcDir {
+			relPath, err := filepath.Rel(srcDir, path)
+			if err != nil {
+				return fmt.Errorf("getting relative path: %w", err)
+			}
+			if (cfg.SkipHiddenFiles && strings.HasPrefix(d.Name(), ".")) || matchesAny(cfg.ExcludeGlobs, filepath.ToSlash(relPath)) {
+				return filepath.SkipDir
+			}
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("watching %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// watchesFile reports whether the file at relPath below the source
+// directory is one walkMarkdownFiles would convert
+func watchesFile(cfg *Config, relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	if cfg.SkipHiddenFiles {
+		for _, part := range strings.Split(relPath, "/") {
+			if strings.HasPrefix(part, ".") {
+				return false
+			}
+		}
+	}
+	return selectsFile(cfg, relPath)
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const memorySampleInterval = 500 * time.Millisecond
+
+// workerLimiter bounds the number of concurrent conversions with a limit
+// that can be lowered and raised while conversions are running
+type workerLimiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	active int
+}
+
+func newWorkerLimiter(limit int) *workerLimiter {
+	l := &workerLimiter{limit: max(limit, 1)}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *workerLimiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+}
+
+func (l *workerLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	l.cond.Broadcast()
+}
+
+func (l *workerLimiter) setLimit(limit int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = max(limit, 1)
+	l.cond.Broadcast()
+}
+
+// autoScaleWorkers samples memory usage every 500ms until ctx is done,
+// halving the worker limit while usage exceeds cfg.MemoryLimitMB and
+// restoring workers one at a time once usage drops below 75% of it.
+// Usage is MemStats.Sys minus the heap memory already returned to the OS.
+func autoScaleWorkers(ctx context.Context, l *workerLimiter, cfg *Config) {
+	if cfg.MemoryLimitMB <= 0 {
+		return
+	}
+	limitBytes := uint64(cfg.MemoryLimitMB) << 20
+
+	ticker := time.NewTicker(memorySampleInterval)
+	defer ticker.Stop()
+
+	workers := max(cfg.MaxConcurrency, 1)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		used := stats.Sys - stats.HeapReleased
+
+		switch {
+		case used > limitBytes && workers > 1:
+			workers = max(workers/2, 1)
+		case used < limitBytes/4*3 && workers < cfg.MaxConcurrency:
+			workers++
+		default:
+			continue
+		}
+		l.setLimit(workers)
+	}
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// usesYAMLNode reports whether YAML output needs post-processing through the Node API
+func (fmc *FrontMatterConverter) usesYAMLNode() bool {
+	return len(fmc.cfg.FrontMatterStyle) > 0 || fmc.cfg.AlwaysQuoteStrings
+}
+
+// yamlNode encodes a front matter map into a YAML node tree and applies
+// the configured per-field styles
+func (fmc *FrontMatterConverter) yamlNode(frontMatter map[string]interface{}) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(frontMatter); err != nil {
+		return nil, err
+	}
+
+	if fmc.cfg.AlwaysQuoteStrings {
+		quoteStrings(&node)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		style, ok := fmc.cfg.FrontMatterStyle[key.Value]
+		if !ok {
+			continue
+		}
+		switch style {
+		case "flow":
+			value.Style |= yaml.FlowStyle
+		case "block":
+			value.Style &^= yaml.FlowStyle
+		default:
+			return nil, fmt.Errorf("unsupported style %q for field %s", style, key.Value)
+		}
+	}
+
+	return &node, nil
+}
+
+// quoteStrings double-quotes every string scalar value below node, leaving mapping keys untouched
+func quoteStrings(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			quoteStrings(node.Content[i])
+		}
+	default:
+		for _, child := range node.Content {
+			quoteStrings(child)
+		}
+	}
+}
+
+// padYAMLKeys pads the "key:" part of every top-level line of rendered
+// YAML whose value starts on the same line to width characters, so that
+// the values line up. A single space always separates key and value.
+func padYAMLKeys(rendered string, width int) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(rendered), &doc); err != nil {
+		return "", fmt.Errorf("aligning front matter: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return rendered, nil
+	}
+
+	lines := strings.Split(rendered, "\n")
+	mapping := doc.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if value.Line != key.Line || key.Column != 1 {
+			continue
+		}
+		line := []rune(lines[key.Line-1])
+		keyPart := strings.TrimRight(string(line[:value.Column-1]), " ")
+		lines[key.Line-1] = fmt.Sprintf("%-*s", width, keyPart) + " " + string(line[value.Column-1:])
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,252 @@
+package tests
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/pplmx/h2h/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		modify   func(cfg *internal.Config)
+		expected []string
+	}{
+		{
+			name:   "Default config",
+			modify: func(cfg *internal.Config) {},
+		},
+		{
+			name: "Valid alternatives",
+			modify: func(cfg *internal.Config) {
+				cfg.SourceFormat = "detect"
+				cfg.TargetFormat = "frontmatter-only-json"
+				cfg.ConversionDirection = "passthrough"
+				cfg.OutputDelimiter = "+++"
+				cfg.NewlineNormalization = "crlf"
+				cfg.LineBreakStrategy = "softbreak"
+				cfg.TagCase = "title"
+				cfg.YAMLIndent = 2
+				cfg.FrontMatterStyle = map[string]string{"tags": "flow"}
+				cfg.FrontMatterTypeHints = map[string]string{"weight": "int", "tags": "[]string"}
+			},
+		},
+		{
+			name:     "Source format",
+			modify:   func(cfg *internal.Config) { cfg.SourceFormat = "xml" },
+			expected: []string{`unsupported source format: "xml"`},
+		},
+		{
+			name:     "Target format",
+			modify:   func(cfg *internal.Config) { cfg.TargetFormat = "detect" },
+			expected: []string{`unsupported target format: "detect"`},
+		},
+		{
+			name:     "Conversion direction",
+			modify:   func(cfg *internal.Config) { cfg.ConversionDirection = "sideways" },
+			expected: []string{`unsupported conversion direction: "sideways"`},
+		},
+		{
+			name:     "Output delimiter",
+			modify:   func(cfg *internal.Config) { cfg.OutputDelimiter = "===" },
+			expected: []string{`unsupported output delimiter: "==="`},
+		},
+		{
+			name:     "Newline normalization",
+			modify:   func(cfg *internal.Config) { cfg.NewlineNormalization = "cr" },
+			expected: []string{`unsupported newline normalization: "cr"`},
+		},
+		{
+			name:     "Line break strategy",
+			modify:   func(cfg *internal.Config) { cfg.LineBreakStrategy = "wrap" },
+			expected: []string{`unsupported line break strategy: "wrap"`},
+		},
+		{
+			name:     "Tag case",
+			modify:   func(cfg *internal.Config) { cfg.TagCase = "camel" },
+			expected: []string{`unsupported tag case: "camel"`},
+		},
+		{
+			name:     "Front matter style",
+			modify:   func(cfg *internal.Config) { cfg.FrontMatterStyle = map[string]string{"tags": "inline"} },
+			expected: []string{`unsupported style "inline" for field tags`},
+		},
+		{
+			name:     "Type hint",
+			modify:   func(cfg *internal.Config) { cfg.FrontMatterTypeHints = map[string]string{"weight": "integer"} },
+			expected: []string{`unsupported type hint "integer" for field weight`},
+		},
+		{
+			name:     "YAML indent",
+			modify:   func(cfg *internal.Config) { cfg.YAMLIndent = 3 },
+			expected: []string{"YAMLIndent must be 2 or 4, or 0 for the default, got 3"},
+		},
+		{
+			name:     "Negative YAML indent",
+			modify:   func(cfg *internal.Config) { cfg.YAMLIndent = -2 },
+			expected: []string{"YAMLIndent must be 2 or 4, or 0 for the default, got -2"},
+		},
+		{
+			name: "Zero indents",
+			modify: func(cfg *internal.Config) {
+				cfg.YAMLIndent = 0
+				cfg.JSONIndent = 0
+				cfg.TOMLIndent = 0
+			},
+		},
+		{
+			name: "Globs",
+			modify: func(cfg *internal.Config) {
+				cfg.IncludeGlobs = []string{"posts/**/*.md", "posts/[a-"}
+				cfg.ExcludeGlobs = []string{"drafts/{a,b"}
+			},
+			expected: []string{
+				`invalid glob pattern "posts/[a-": syntax error in pattern`,
+				`invalid glob pattern "drafts/{a,b": syntax error in pattern`,
+			},
+		},
+		{
+			name: "Valid keys",
+			modify: func(cfg *internal.Config) {
+				cfg.FrontMatterEncryptFields = []string{"secret"}
+				cfg.EncryptionKey = strings.Repeat("ab", 32)
+				cfg.DigestFrontMatter = true
+				cfg.DigestKey = "0102"
+				cfg.EnableOpenMetrics = true
+				cfg.OpenMetricsFile = "metrics.txt"
+			},
+		},
+		{
+			name:     "Missing encryption key",
+			modify:   func(cfg *internal.Config) { cfg.FrontMatterEncryptFields = []string{"secret"} },
+			expected: []string{"invalid EncryptionKey: encryption key must be 32 bytes, got 0"},
+		},
+		{
+			name: "Malformed encryption key",
+			modify: func(cfg *internal.Config) {
+				cfg.FrontMatterEncryptFields = []string{"secret"}
+				cfg.EncryptionKey = "xyz"
+			},
+			expected: []string{"invalid EncryptionKey: decoding encryption key: encoding/hex: invalid byte: U+0078 'x'"},
+		},
+		{
+			name:     "Missing digest key",
+			modify:   func(cfg *internal.Config) { cfg.DigestFrontMatter = true },
+			expected: []string{"DigestKey must be set when DigestFrontMatter is"},
+		},
+		{
+			name: "Malformed digest key",
+			modify: func(cfg *internal.Config) {
+				cfg.DigestFrontMatter = true
+				cfg.DigestKey = "abc"
+			},
+			expected: []string{"invalid DigestKey: encoding/hex: odd length hex string"},
+		},
+		{
+			name:     "Missing metrics file",
+			modify:   func(cfg *internal.Config) { cfg.EnableOpenMetrics = true },
+			expected: []string{"OpenMetricsFile must be set when EnableOpenMetrics is"},
+		},
+		{
+			name: "Negative numbers",
+			modify: func(cfg *internal.Config) {
+				cfg.JSONIndent = -1
+				cfg.TOMLIndent = -1
+				cfg.FrontMatterFieldWidth = -1
+				cfg.FrontMatterMarshalPrecision = -1
+				cfg.TruncateDescription = -1
+				cfg.MaxLineLength = -1
+				cfg.SplitAtLines = -1
+				cfg.MemoryLimitMB = -1
+				cfg.ReadingSpeedWPM = -1
+				cfg.OutputBufferSize = -1
+				cfg.FileRetries = -1
+				cfg.LogMaxSizeMB = -1
+				cfg.LogMaxBackups = -1
+			},
+			expected: []string{
+				"JSONIndent must not be negative, got -1",
+				"TOMLIndent must not be negative, got -1",
+				"FrontMatterFieldWidth must not be negative, got -1",
+				"FrontMatterMarshalPrecision must not be negative, got -1",
+				"TruncateDescription must not be negative, got -1",
+				"MaxLineLength must not be negative, got -1",
+				"SplitAtLines must not be negative, got -1",
+				"MemoryLimitMB must not be negative, got -1",
+				"ReadingSpeedWPM must not be negative, got -1",
+				"OutputBufferSize must not be negative, got -1",
+				"FileRetries must not be negative, got -1",
+				"LogMaxSizeMB must not be negative, got -1",
+				"LogMaxBackups must not be negative, got -1",
+			},
+		},
+		{
+			name: "Every problem is reported",
+			modify: func(cfg *internal.Config) {
+				cfg.SourceFormat = "xml"
+				cfg.TargetFormat = "html"
+				cfg.ConversionDirection = "sideways"
+			},
+			expected: []string{
+				`unsupported source format: "xml"`,
+				`unsupported target format: "html"`,
+				`unsupported conversion direction: "sideways"`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			tc.modify(cfg)
+			err := cfg.Validate()
+			if len(tc.expected) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tc.expected, unwrapJoined(err))
+		})
+	}
+}
+
+func TestConfigValidateHandBuilt(t *testing.T) {
+	cfg := &internal.Config{
+		SourceFormat:        "yaml",
+		TargetFormat:        "yaml",
+		ConversionDirection: "hexo2hugo",
+		MaxConcurrency:      1,
+	}
+	require.NoError(t, cfg.Validate())
+
+	var out bytes.Buffer
+	mc := internal.NewMarkdownConverter(cfg)
+	require.NoError(t, mc.ConvertMarkdown(strings.NewReader("---\ntitle: Hand\ntags: [go]\n---\nBody\n"), &out))
+	assert.Equal(t, "---\ntags:\n    - go\ntitle: Hand\n---\nBody\n", out.String())
+}
+
+func TestConfigValidateMaxConcurrency(t *testing.T) {
+	var logs bytes.Buffer
+	cfg := internal.NewDefaultConfig()
+	cfg.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	cfg.MaxConcurrency = 0
+
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, 1, cfg.MaxConcurrency)
+	assert.Contains(t, logs.String(), "level=WARN")
+	assert.Contains(t, logs.String(), "max_concurrency=0")
+}
+
+// unwrapJoined returns the messages of the errors joined into err
+func unwrapJoined(err error) []string {
+	var messages []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		messages = append(messages, e.Error())
+	}
+	return messages
+}
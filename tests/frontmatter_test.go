@@ -0,0 +1,1042 @@
+package tests
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pplmx/h2h/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertFrontMatterMapUnmappedKeys(t *testing.T) {
+	source := map[string]interface{}{
+		"title":     "Test Post",
+		"permalink": "test-post",
+		"toc":       true,
+		"params":    map[string]interface{}{"author": "me"},
+	}
+
+	testCases := []struct {
+		name              string
+		newKeyForUnmapped string
+		expected          map[string]interface{}
+	}{
+		{
+			name: "Pass through",
+			expected: map[string]interface{}{
+				"title":  "Test Post",
+				"slug":   "test-post",
+				"toc":    true,
+				"params": map[string]interface{}{"author": "me"},
+			},
+		},
+		{
+			name:              "Nested under params",
+			newKeyForUnmapped: "params",
+			expected: map[string]interface{}{
+				"title":  "Test Post",
+				"slug":   "test-post",
+				"params": map[string]interface{}{"author": "me", "toc": true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.NewKeyForUnmapped = tc.newKeyForUnmapped
+			fmc := internal.NewFrontMatterConverter(cfg)
+
+			converted, err := fmc.ConvertFrontMatterMap(source)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, converted)
+		})
+	}
+}
+
+func TestConvertFrontMatterMapPublishedToDraft(t *testing.T) {
+	testCases := []struct {
+		name      string
+		direction string
+		source    map[string]interface{}
+		expected  map[string]interface{}
+	}{
+		{name: "Unpublished", direction: "hexo2hugo", source: map[string]interface{}{"published": false}, expected: map[string]interface{}{"draft": true}},
+		{name: "Published", direction: "hexo2hugo", source: map[string]interface{}{"published": true}, expected: map[string]interface{}{"draft": false}},
+		{name: "Missing", direction: "hexo2hugo", source: map[string]interface{}{"title": "Post"}, expected: map[string]interface{}{"title": "Post"}},
+		{name: "Draft", direction: "hugo2hexo", source: map[string]interface{}{"draft": true}, expected: map[string]interface{}{"published": false}},
+		{name: "Not a draft", direction: "hugo2hexo", source: map[string]interface{}{"draft": false}, expected: map[string]interface{}{"published": true}},
+		{name: "Passthrough", direction: "passthrough", source: map[string]interface{}{"published": false}, expected: map[string]interface{}{"published": false}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.ConversionDirection = tc.direction
+			converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(tc.source)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, converted)
+		})
+	}
+}
+
+func TestConvertFrontMatterMapAliases(t *testing.T) {
+	testCases := []struct {
+		name      string
+		direction string
+		source    map[string]interface{}
+		expected  map[string]interface{}
+	}{
+		{name: "String wrapped", direction: "hexo2hugo", source: map[string]interface{}{"redirect_from": "/old/"}, expected: map[string]interface{}{"aliases": []interface{}{"/old/"}}},
+		{name: "List kept", direction: "hexo2hugo", source: map[string]interface{}{"redirect_from": []interface{}{"/a/", "/b/"}}, expected: map[string]interface{}{"aliases": []interface{}{"/a/", "/b/"}}},
+		{name: "Single element unwrapped", direction: "hugo2hexo", source: map[string]interface{}{"aliases": []interface{}{"/old/"}}, expected: map[string]interface{}{"redirect_from": "/old/"}},
+		{name: "Multiple elements kept", direction: "hugo2hexo", source: map[string]interface{}{"aliases": []interface{}{"/a/", "/b/"}}, expected: map[string]interface{}{"redirect_from": []interface{}{"/a/", "/b/"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.ConversionDirection = tc.direction
+			converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(tc.source)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, converted)
+		})
+	}
+}
+
+func TestConvertFrontMatterMapImageKeys(t *testing.T) {
+	testCases := []struct {
+		name      string
+		imageKeys map[string]string
+		rewrite   bool
+		source    map[string]interface{}
+		expected  map[string]interface{}
+	}{
+		{
+			name:     "Default keys",
+			source:   map[string]interface{}{"cover": "/img/foo.png"},
+			expected: map[string]interface{}{"images": []interface{}{"/img/foo.png"}},
+		},
+		{
+			name:     "Rewritten paths",
+			rewrite:  true,
+			source:   map[string]interface{}{"thumbnail": "/img/foo.png"},
+			expected: map[string]interface{}{"images": []interface{}{"foo.png"}},
+		},
+		{
+			name:     "URLs kept",
+			rewrite:  true,
+			source:   map[string]interface{}{"cover_image": []interface{}{"https://example.com/foo.png", "/img/bar.png"}},
+			expected: map[string]interface{}{"images": []interface{}{"https://example.com/foo.png", "bar.png"}},
+		},
+		{
+			name:      "Custom keys",
+			imageKeys: map[string]string{"banner": "featured_image"},
+			rewrite:   true,
+			source:    map[string]interface{}{"banner": "/img/foo.png", "cover": "/img/bar.png"},
+			expected:  map[string]interface{}{"featured_image": "foo.png", "cover": "/img/bar.png"},
+		},
+		{
+			name:     "Colliding keys merged in key order",
+			source:   map[string]interface{}{"title": "x", "thumbnail": "/b.png", "cover": "/a.png", "feature": []interface{}{"/c.png", "/a.png"}},
+			expected: map[string]interface{}{"title": "x", "images": []interface{}{"/a.png", "/c.png", "/b.png"}},
+		},
+		{
+			name:      "Colliding strings keep the first key",
+			imageKeys: map[string]string{"banner": "featured_image", "hero": "featured_image"},
+			source:    map[string]interface{}{"hero": "/b.png", "banner": "/a.png"},
+			expected:  map[string]interface{}{"featured_image": "/a.png"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			if tc.imageKeys != nil {
+				cfg.ImageKeys = tc.imageKeys
+			}
+			cfg.RewriteImagePaths = tc.rewrite
+			// map iteration order varies, so a collision has to give the
+			// same result every time
+			for range 50 {
+				converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(tc.source)
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, converted)
+			}
+		})
+	}
+}
+
+func TestConvertFrontMatterMapNestedParams(t *testing.T) {
+	testCases := []struct {
+		name      string
+		direction string
+		flatten   bool
+		unmapped  string
+		source    map[string]interface{}
+		expected  map[string]interface{}
+	}{
+		{
+			name:      "Flat",
+			direction: "hexo2hugo",
+			flatten:   true,
+			source:    map[string]interface{}{"author": "me"},
+			expected:  map[string]interface{}{"author": "me"},
+		},
+		{
+			name:      "Nested",
+			direction: "hexo2hugo",
+			source:    map[string]interface{}{"author": "me", "params": map[string]interface{}{"toc": true}},
+			expected:  map[string]interface{}{"params": map[string]interface{}{"author": "me", "toc": true}},
+		},
+		{
+			name:      "Nested with unmapped keys under params",
+			direction: "hexo2hugo",
+			unmapped:  "params",
+			source:    map[string]interface{}{"author": "me", "toc": true},
+			expected:  map[string]interface{}{"params": map[string]interface{}{"author": "me", "toc": true}},
+		},
+		{
+			name:      "Lifted",
+			direction: "hugo2hexo",
+			source:    map[string]interface{}{"params": map[string]interface{}{"author": "me"}},
+			expected:  map[string]interface{}{"author": "me"},
+		},
+		{
+			name:      "Lifted keeping other params",
+			direction: "hugo2hexo",
+			source:    map[string]interface{}{"params": map[string]interface{}{"author": "me", "toc": true}},
+			expected:  map[string]interface{}{"author": "me", "params": map[string]interface{}{"toc": true}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.ConversionDirection = tc.direction
+			cfg.FlattenParams = tc.flatten
+			cfg.NewKeyForUnmapped = tc.unmapped
+			converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(tc.source)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, converted)
+		})
+	}
+}
+
+func TestConvertFrontMatterMapKeyWhitelistMode(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.KeyWhitelistMode = true
+	cfg.NewKeyForUnmapped = "params"
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{
+		"title":     "Minimal",
+		"date":      "2023-05-01",
+		"tags":      []interface{}{"go"},
+		"published": false,
+		"comments":  true,
+		"reward":    true,
+		"toc":       true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"title": "Minimal",
+		"date":  "2023-05-01",
+		"tags":  []interface{}{"go"},
+		"draft": true,
+	}, converted)
+}
+
+func TestConvertFrontMatterMapDropKeys(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.DropKeys = []string{"abbrlink", "mathjax", "slug", "params.sticky", "missing"}
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{
+		"title":     "Dropped",
+		"permalink": "dropped",
+		"abbrlink":  "1a2b",
+		"mathjax":   true,
+		"params":    map[string]interface{}{"sticky": 1, "toc": true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"title":  "Dropped",
+		"params": map[string]interface{}{"toc": true},
+	}, converted)
+}
+
+func TestConvertFrontMatterMapOpenGraphFields(t *testing.T) {
+	source := map[string]interface{}{
+		"title":          "Open Graph",
+		"description":    "A post.",
+		"featured_image": "cover.png",
+		"og_title":       "Custom",
+	}
+
+	cfg := internal.NewDefaultConfig()
+	cfg.GenerateOpenGraphFields = true
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(source)
+	require.NoError(t, err)
+	assert.Equal(t, "Custom", converted["og_title"], "existing fields are kept")
+	assert.Equal(t, "A post.", converted["og_description"])
+	assert.Equal(t, "cover.png", converted["og_image"])
+
+	cfg.OGFieldMap = map[string]string{"og_site_title": "title"}
+	converted, err = internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(source)
+	require.NoError(t, err)
+	assert.Equal(t, "Open Graph", converted["og_site_title"])
+	assert.NotContains(t, converted, "og_description")
+
+	cfg.GenerateOpenGraphFields = false
+	converted, err = internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(source)
+	require.NoError(t, err)
+	assert.NotContains(t, converted, "og_site_title")
+}
+
+func TestConvertFrontMatterMapSchemaOrgFields(t *testing.T) {
+	date := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	cfg := internal.NewDefaultConfig()
+	cfg.GenerateSchemaOrgFields = true
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{
+		"title":  "Structured",
+		"date":   date,
+		"author": "Jane",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"@type":         "BlogPosting",
+		"headline":      "Structured",
+		"datePublished": date,
+		"author":        map[string]interface{}{"@type": "Person", "name": "Jane"},
+	}, converted["schema_org"])
+
+	cfg.FlattenParams = false
+	converted, err = internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{"title": "Nested", "author": "Jane"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"@type":    "BlogPosting",
+		"headline": "Nested",
+		"author":   map[string]interface{}{"@type": "Person", "name": "Jane"},
+	}, converted["schema_org"])
+}
+
+func TestConvertFrontMatterMapInjectKeys(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.InjectKeys = map[string]interface{}{
+		"hugo":     internal.ParseFrontMatterValue("true"),
+		"migrated": internal.ParseFrontMatterValue("2024-01-01"),
+		"weight":   internal.ParseFrontMatterValue("10"),
+		"title":    internal.ParseFrontMatterValue("Injected"),
+	}
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{"title": "Original"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"hugo":     true,
+		"migrated": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		"weight":   int64(10),
+		"title":    "Injected",
+	}, converted)
+	assert.Equal(t, "no", internal.ParseFrontMatterValue("no"))
+}
+
+func TestConvertFrontMatterMapTypeHints(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.FrontMatterTypeHints = map[string]string{
+		"weight":  "int",
+		"rating":  "float",
+		"toc":     "bool",
+		"version": "string",
+		"tags":    "[]string",
+		"missing": "int",
+	}
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{
+		"weight":  "10",
+		"rating":  4,
+		"toc":     "true",
+		"version": 1.2,
+		"tags":    "go",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"weight":  int64(10),
+		"rating":  4.0,
+		"toc":     true,
+		"version": "1.2",
+		"tags":    []interface{}{"go"},
+	}, converted)
+
+	_, err = internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{"weight": "heavy"})
+	assert.ErrorContains(t, err, "coercing weight to int")
+
+	cfg.FrontMatterTypeHints = map[string]string{"weight": "duration"}
+	_, err = internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{"weight": "10"})
+	assert.ErrorContains(t, err, "unsupported type hint")
+}
+
+func TestConvertFrontMatterKeywordsFromTags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   string
+		source   string
+		merge    bool
+		expected string
+	}{
+		{name: "YAML sequence", format: "yaml", source: "\ntags:\n  - go\n  - hugo\n", expected: "keywords:\n    - go\n    - hugo\ntags:\n    - go\n    - hugo\n"},
+		{name: "TOML array", format: "toml", source: "\ntags = [\"go\", \"hugo\"]\n", expected: "keywords:\n    - go\n    - hugo\ntags:\n    - go\n    - hugo\n"},
+		{name: "Existing keywords kept", format: "yaml", source: "\nkeywords: [seo]\ntags: [go]\n", expected: "keywords:\n    - seo\ntags:\n    - go\n"},
+		{name: "Merged", format: "yaml", merge: true, source: "\nkeywords: [seo, go]\ntags: [go, hugo]\n", expected: "keywords:\n    - seo\n    - go\n    - hugo\ntags:\n    - go\n    - hugo\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.SourceFormat = tc.format
+			cfg.KeywordsFromTags = true
+			cfg.MergeKeywords = tc.merge
+			converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatter(tc.source)
+			require.NoError(t, err)
+			assert.Equal(t, "---\n"+tc.expected+"---", converted)
+		})
+	}
+}
+
+func TestConvertFrontMatterMapTruncateDescription(t *testing.T) {
+	testCases := []struct {
+		name        string
+		description string
+		limit       int
+		expected    string
+	}{
+		{name: "Disabled", description: "A long description of the post", limit: 0, expected: "A long description of the post"},
+		{name: "Short enough", description: "Short", limit: 10, expected: "Short"},
+		{name: "Word boundary", description: "A long description of the post", limit: 15, expected: "A long ..."},
+		{name: "Limit at space", description: "A long description of the post", limit: 18, expected: "A long description ..."},
+		{name: "Multi-byte words", description: "Ünïcödé wörds äré fïné", limit: 14, expected: "Ünïcödé wörds ..."},
+		{name: "Multi-byte without spaces", description: "这是一个很长的描述", limit: 4, expected: "这是一个 ..."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.TruncateDescription = tc.limit
+			fmc := internal.NewFrontMatterConverter(cfg)
+
+			converted, err := fmc.ConvertFrontMatterMap(map[string]interface{}{"description": tc.description})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, converted["description"])
+		})
+	}
+}
+
+func TestConvertFrontMatterMapSanitizeSlug(t *testing.T) {
+	testCases := []struct {
+		permalink string
+		expected  string
+	}{
+		{permalink: "my-post", expected: "my-post"},
+		{permalink: "My First Post", expected: "my-first-post"},
+		{permalink: "  Hello,   World!  ", expected: "hello-world"},
+		{permalink: "Crème Brûlée -- Recipe", expected: "creme-brulee-recipe"},
+		{permalink: "/2023/05/post.html", expected: "202305posthtml"},
+	}
+
+	cfg := internal.NewDefaultConfig()
+	cfg.SanitizeSlug = true
+	fmc := internal.NewFrontMatterConverter(cfg)
+
+	for _, tc := range testCases {
+		t.Run(tc.permalink, func(t *testing.T) {
+			converted, err := fmc.ConvertFrontMatterMap(map[string]interface{}{"permalink": tc.permalink})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, converted["slug"])
+		})
+	}
+}
+
+func TestConvertFrontMatterStyle(t *testing.T) {
+	frontMatter := "\ntitle: Styled\ntags:\n  - go\n  - hugo\ncategories: [web]\n"
+
+	cfg := internal.NewDefaultConfig()
+	cfg.FrontMatterStyle = map[string]string{"tags": "flow", "categories": "block"}
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatter(frontMatter)
+	require.NoError(t, err)
+
+	assert.Contains(t, converted, "tags: [go, hugo]\n")
+	assert.Contains(t, converted, "categories:\n    - web\n")
+
+	cfg.FrontMatterStyle = map[string]string{"tags": "inline"}
+	_, err = internal.NewFrontMatterConverter(cfg).ConvertFrontMatter(frontMatter)
+	assert.ErrorContains(t, err, `unsupported style "inline" for field tags`)
+}
+
+func TestConvertFrontMatterAlwaysQuoteStrings(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.AlwaysQuoteStrings = true
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatter("\ntitle: Quoted\ndraft: false\nweight: 3\ntags: [go, \"yes\"]\n")
+	require.NoError(t, err)
+
+	assert.Contains(t, converted, `title: "Quoted"`)
+	assert.Contains(t, converted, "draft: false\n")
+	assert.Contains(t, converted, "weight: 3\n")
+	assert.Contains(t, converted, `- "go"`)
+	assert.Contains(t, converted, `- "yes"`)
+}
+
+func TestConvertFrontMatterDetectFormat(t *testing.T) {
+	testCases := []struct {
+		name        string
+		frontMatter string
+		force       bool
+		expected    string
+	}{
+		{name: "YAML kept", frontMatter: "\ntitle: Detected\n", expected: "title: Detected\n"},
+		{name: "TOML kept", frontMatter: "\ntitle = 'Detected'\n", expected: "title = \"Detected\"\n"},
+		{name: "TOML forced to YAML", frontMatter: "\ntitle = 'Detected'\n", force: true, expected: "title: Detected\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.SourceFormat = "detect"
+			cfg.ForceTargetFormat = tc.force
+			converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatter(tc.frontMatter)
+			require.NoError(t, err)
+			assert.Equal(t, "---\n"+tc.expected+"---", converted)
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	testCases := map[string]string{
+		"Hello World":          "hello-world",
+		"  Café au Lait!  ":    "cafe-au-lait",
+		"Go 1.23: What's New?": "go-123-whats-new",
+		"中文 标题":                "中文-标题",
+		"---":                  "",
+	}
+	for title, expected := range testCases {
+		assert.Equal(t, expected, internal.Slugify(title), title)
+	}
+}
+
+func TestConvertFrontMatterMapSlugFromTitle(t *testing.T) {
+	testCases := []struct {
+		name      string
+		direction string
+		source    map[string]interface{}
+		key       string
+		expected  interface{}
+	}{
+		{
+			name:     "Generated",
+			source:   map[string]interface{}{"title": "Hello, Wörld!"},
+			key:      "slug",
+			expected: "hello-world",
+		},
+		{
+			name:     "Existing permalink kept",
+			source:   map[string]interface{}{"title": "Hello", "permalink": "custom"},
+			key:      "slug",
+			expected: "custom",
+		},
+		{
+			name:      "Hexo permalink",
+			direction: "hugo2hexo",
+			source:    map[string]interface{}{"title": "Hello Hexo"},
+			key:       "permalink",
+			expected:  "hello-hexo",
+		},
+		{
+			name:   "No title",
+			source: map[string]interface{}{"date": "2023-05-01"},
+			key:    "slug",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.SlugFromTitle = true
+			if tc.direction != "" {
+				cfg.ConversionDirection = tc.direction
+			}
+
+			converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(tc.source)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, converted[tc.key])
+		})
+	}
+}
+
+func TestConvertFrontMatterMapNewlineNormalization(t *testing.T) {
+	testCases := []struct {
+		mode     string
+		expected string
+	}{
+		{mode: "none", expected: "one\r\ntwo\nthree"},
+		{mode: "lf", expected: "one\ntwo\nthree"},
+		{mode: "crlf", expected: "one\r\ntwo\r\nthree"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.mode, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.NewlineNormalization = tc.mode
+			converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{
+				"description": "one\r\ntwo\nthree",
+				"params":      map[string]interface{}{"notes": []interface{}{"one\r\ntwo\nthree"}},
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expected, converted["description"])
+			assert.Equal(t, tc.expected, converted["params"].(map[string]interface{})["notes"].([]interface{})[0])
+		})
+	}
+
+	cfg := internal.NewDefaultConfig()
+	cfg.NewlineNormalization = "cr"
+	_, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{})
+	assert.ErrorContains(t, err, "unsupported newline normalization: cr")
+}
+
+func TestConvertFrontMatterEmptyStringAsNull(t *testing.T) {
+	frontMatter := "\ntitle: Empty\ndescription: \"\"\nparams:\n  subtitle: \"\"\ntags: [\"\", go]\n"
+
+	testCases := []struct {
+		format   string
+		expected []string
+		absent   []string
+	}{
+		{
+			format:   "yaml",
+			expected: []string{"description: null\n", "subtitle: null\n", "- \"\"\n"},
+		},
+		{
+			format:   "toml",
+			expected: []string{"title = \"Empty\"\n", "tags = [\"\", \"go\"]\n"},
+			absent:   []string{"description", "subtitle"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.TargetFormat = tc.format
+			cfg.EmptyStringAsNull = true
+			converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatter(frontMatter)
+			require.NoError(t, err)
+
+			for _, expected := range tc.expected {
+				assert.Contains(t, converted, expected)
+			}
+			for _, absent := range tc.absent {
+				assert.NotContains(t, converted, absent)
+			}
+		})
+	}
+}
+
+func TestConvertFrontMatterPassthrough(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.ConversionDirection = "passthrough"
+	cfg.TargetFormat = "toml"
+	cfg.NewKeyForUnmapped = "params"
+
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatter("\ntitle: Same\npermalink: same\ndate: 2024-01-02\n")
+	require.NoError(t, err)
+	assert.Equal(t, "---\ndate = 2024-01-02T00:00:00Z\npermalink = \"same\"\ntitle = \"Same\"\n---", converted)
+}
+
+func TestConvertFrontMatterMapStripMarkdownFormatting(t *testing.T) {
+	testCases := []struct {
+		name        string
+		description string
+		expected    string
+	}{
+		{name: "Bold", description: "A **bold** and _emphasised_ claim", expected: "A bold and emphasised claim"},
+		{name: "Link", description: "Read [the docs](https://gohugo.io/) first.", expected: "Read the docs first."},
+		{name: "Code span", description: "Use `h2h --src` & friends", expected: "Use h2h --src & friends"},
+		{name: "Paragraphs", description: "First line\n\nSecond line", expected: "First line Second line"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.StripMarkdownFormatting = true
+			fmc := internal.NewFrontMatterConverter(cfg)
+
+			converted, err := fmc.ConvertFrontMatterMap(map[string]interface{}{
+				"description": tc.description,
+				"excerpt":     tc.description,
+				"title":       "**Kept**",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, converted["description"])
+			assert.Equal(t, tc.expected, converted["excerpt"])
+			assert.Equal(t, "**Kept**", converted["title"])
+		})
+	}
+}
+
+func TestConvertFrontMatterMapKeyAlias(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   map[string]interface{}
+		expected map[string]interface{}
+		logged   bool
+	}{
+		{
+			name:     "Alias used",
+			source:   map[string]interface{}{"title": "Alias", "tag": []interface{}{"go"}},
+			expected: map[string]interface{}{"title": "Alias", "tags": []interface{}{"go"}},
+			logged:   true,
+		},
+		{
+			name:     "Second alias",
+			source:   map[string]interface{}{"title": "Alias", "keywords": []interface{}{"go"}},
+			expected: map[string]interface{}{"title": "Alias", "tags": []interface{}{"go"}},
+			logged:   true,
+		},
+		{
+			name:     "Canonical key wins",
+			source:   map[string]interface{}{"tags": []interface{}{"go"}, "tag": "web"},
+			expected: map[string]interface{}{"tags": []interface{}{"go"}, "tag": "web"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			cfg := internal.NewDefaultConfig()
+			cfg.Logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			fmc := internal.NewFrontMatterConverter(cfg)
+			fmc.AddKeyAlias("tags", "tag")
+			fmc.AddKeyAlias("tags", "keywords")
+
+			converted, err := fmc.ConvertFrontMatterMap(tc.source)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, converted)
+			if tc.logged {
+				assert.Contains(t, logs.String(), "using key alias")
+			} else {
+				assert.Empty(t, logs.String())
+			}
+		})
+	}
+}
+
+func TestConvertFrontMatterMapNormalizeDates(t *testing.T) {
+	testCases := []struct {
+		name      string
+		direction string
+		layout    string
+		source    map[string]interface{}
+		expected  map[string]interface{}
+		warning   bool
+	}{
+		{
+			name:      "Hexo string to RFC 3339",
+			direction: "hexo2hugo",
+			source:    map[string]interface{}{"date": "2023-05-01", "updated": "2023/05/02 10:30:00"},
+			expected: map[string]interface{}{
+				"date":    time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+				"lastmod": time.Date(2023, 5, 2, 10, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:      "Parsed time to RFC 3339",
+			direction: "hexo2hugo",
+			source:    map[string]interface{}{"date": time.Date(2023, 5, 1, 8, 0, 0, 500, time.FixedZone("", 8*3600))},
+			expected:  map[string]interface{}{"date": time.Date(2023, 5, 1, 8, 0, 0, 0, time.FixedZone("", 8*3600))},
+		},
+		{
+			name:      "Explicit RFC 3339 layout",
+			direction: "hexo2hugo",
+			layout:    time.RFC3339,
+			source:    map[string]interface{}{"date": "2023-05-01 10:00"},
+			expected:  map[string]interface{}{"date": time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:      "Hugo to date only",
+			direction: "hugo2hexo",
+			source:    map[string]interface{}{"date": "2023-05-01T10:00:00+08:00", "lastmod": "2023-05-02"},
+			expected:  map[string]interface{}{"date": "2023-05-01", "updated": "2023-05-02"},
+		},
+		{
+			name:      "Custom layout",
+			direction: "hexo2hugo",
+			layout:    "Jan 2, 2006",
+			source:    map[string]interface{}{"date": "2023-05-01"},
+			expected:  map[string]interface{}{"date": "May 1, 2023"},
+		},
+		{
+			name:      "Unparseable date kept",
+			direction: "hexo2hugo",
+			source:    map[string]interface{}{"date": "last tuesday"},
+			expected:  map[string]interface{}{"date": "last tuesday"},
+			warning:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			cfg := internal.NewDefaultConfig()
+			cfg.ConversionDirection = tc.direction
+			cfg.NormalizeDates = true
+			cfg.DateLayout = tc.layout
+			cfg.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(tc.source)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, converted)
+			assert.Equal(t, tc.warning, bytes.Contains(logs.Bytes(), []byte("level=WARN")))
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{name: "YAML", data: "title: YAML\ntags: [go]\n", expected: "yaml"},
+		{name: "TOML", data: "title = \"TOML\"\ntags = [\"go\"]\n", expected: "toml"},
+		{name: "JSON", data: "{\"title\": \"JSON\"}", expected: "json"},
+	}
+
+	fmc := internal.NewFrontMatterConverter(internal.NewDefaultConfig())
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := fmc.DetectFormat([]byte(tc.data))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, format)
+		})
+	}
+
+	_, err := fmc.DetectFormat([]byte("title: [unclosed\nweight = = 2\n"))
+	assert.Error(t, err)
+}
+
+func TestConvertFrontMatterMapRequireFields(t *testing.T) {
+	source := map[string]interface{}{"title": "Required", "author": nil}
+
+	t.Run("Null sentinel", func(t *testing.T) {
+		cfg := internal.NewDefaultConfig()
+		cfg.RequireFields = []string{"title", "author", "summary", "image"}
+		cfg.FrontMatterNullChar = "N/A"
+
+		converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(source)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"title": "Required", "author": nil, "summary": "N/A", "image": "N/A"}, converted)
+	})
+
+	t.Run("Missing fields fail", func(t *testing.T) {
+		cfg := internal.NewDefaultConfig()
+		cfg.RequireFields = []string{"title", "summary", "image"}
+
+		_, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(source)
+		assert.EqualError(t, err, "missing required fields: summary, image")
+	})
+}
+
+func TestConvertFrontMatterMapFailOnUnmappedKeys(t *testing.T) {
+	source := map[string]interface{}{"title": "Strict", "updated": "2023-05-02", "toc": true, "series": "go"}
+
+	cfg := internal.NewDefaultConfig()
+	cfg.FailOnUnmappedKeys = true
+	_, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(source)
+	assert.EqualError(t, err, "unmapped front matter keys: series, toc")
+
+	cfg.IgnoreFields = []string{"toc", "series"}
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(source)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"title": "Strict", "lastmod": "2023-05-02", "toc": true, "series": "go"}, converted)
+
+	cfg = internal.NewDefaultConfig()
+	cfg.FailOnUnmappedKeys = true
+	cfg.ConversionDirection = "passthrough"
+	_, err = internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(source)
+	assert.NoError(t, err, "every key is mapped in passthrough mode")
+}
+
+func TestConvertFrontMatterMapInjectH2HVersion(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.InjectH2HVersion = true
+	cfg.ConversionDirection = "hugo2hexo"
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{"title": "Versioned"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, converted["_h2h_version"])
+	assert.Equal(t, "hugo2hexo", converted["_h2h_direction"])
+
+	cfg.InjectH2HVersion = false
+	converted, err = internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{"title": "Versioned"})
+	require.NoError(t, err)
+	assert.NotContains(t, converted, "_h2h_version")
+}
+
+func TestConvertFrontMatterMapRawTagsField(t *testing.T) {
+	patchFile := filepath.Join(t.TempDir(), "patch.json")
+	require.NoError(t, os.WriteFile(patchFile, []byte(`[{"op": "add", "path": "/tags/-", "value": "hugo"}]`), 0644))
+
+	cfg := internal.NewDefaultConfig()
+	cfg.FrontMatterPatchFile = patchFile
+	cfg.RawTagsField = "original_tags"
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{
+		"title": "Raw",
+		"tags":  []interface{}{"go"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"go", "hugo"}, converted["tags"])
+	assert.Equal(t, []interface{}{"go"}, converted["original_tags"])
+
+	cfg.RawTagsField = ""
+	converted, err = internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{
+		"title": "Raw",
+		"tags":  []interface{}{"go"},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, converted, "original_tags")
+}
+
+func TestConvertFrontMatterMapRawListFields(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.NormalizeTags = true
+	cfg.RawTagsField = "original_tags"
+	cfg.RawListFields = map[string]string{"categories": "original_categories", "keywords": "original_keywords"}
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{
+		"title":      "Raw",
+		"tags":       []interface{}{"Go", "go"},
+		"categories": []interface{}{" Tech ", "tech"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"go"}, converted["tags"])
+	assert.Equal(t, []interface{}{"Go", "go"}, converted["original_tags"])
+	assert.Equal(t, []interface{}{"tech"}, converted["categories"])
+	assert.Equal(t, []interface{}{" Tech ", "tech"}, converted["original_categories"])
+	assert.NotContains(t, converted, "original_keywords", "missing fields are not copied")
+}
+
+func TestConvertFrontMatterMapNormalizeTags(t *testing.T) {
+	testCases := []struct {
+		tagCase  string
+		expected []interface{}
+	}{
+		{tagCase: "", expected: []interface{}{"go", "hugo blog"}},
+		{tagCase: "upper", expected: []interface{}{"GO", "HUGO BLOG"}},
+		{tagCase: "title", expected: []interface{}{"Go", "Hugo Blog"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.tagCase, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.NormalizeTags = true
+			cfg.TagCase = tc.tagCase
+			converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{
+				"tags":       []interface{}{"Go", "go", " GO ", "", "hugo blog"},
+				"keywords":   []interface{}{"Go", "HUGO blog"},
+				"categories": []interface{}{" go"},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, converted["tags"])
+			assert.Equal(t, tc.expected, converted["keywords"])
+			assert.Equal(t, tc.expected[:1], converted["categories"])
+		})
+	}
+
+	cfg := internal.NewDefaultConfig()
+	cfg.NormalizeTags = true
+	cfg.TagCase = "camel"
+	_, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{"tags": []interface{}{"go"}})
+	assert.Error(t, err)
+}
+
+func TestConvertFrontMatterMarshalPrecision(t *testing.T) {
+	source := "\nratio: 0.30000000000000004\nscores: [1.23456, 2]\nparams:\n  weight: 0.125\n"
+
+	cfg := internal.NewDefaultConfig()
+	cfg.TargetFormat = "toml"
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatter(source)
+	require.NoError(t, err)
+	assert.Contains(t, converted, "ratio = 0.30000000000000004")
+
+	cfg.FrontMatterMarshalPrecision = 2
+	converted, err = internal.NewFrontMatterConverter(cfg).ConvertFrontMatter(source)
+	require.NoError(t, err)
+	assert.Equal(t, "---\nratio = 0.3\nscores = [1.23, 2]\n\n[params]\n  weight = 0.13\n---", converted)
+}
+
+func TestConvertFrontMatterPatchFile(t *testing.T) {
+	patchFile := filepath.Join(t.TempDir(), "patch.json")
+	require.NoError(t, os.WriteFile(patchFile, []byte(`[
+		{"op": "add", "path": "/params", "value": {"migrated": true}},
+		{"op": "replace", "path": "/author", "value": "Editorial Team"},
+		{"op": "remove", "path": "/draft"},
+		{"op": "add", "path": "/tags/-", "value": "hugo"}
+	]`), 0644))
+
+	cfg := internal.NewDefaultConfig()
+	cfg.TargetFormat = "toml"
+	cfg.FrontMatterPatchFile = patchFile
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatter("\ntitle: Patched\nauthor: me\ndraft: true\ndate: 2023-05-01\nratio: 1.0\ntags: [go]\n")
+	require.NoError(t, err)
+	assert.Equal(t, "---\nauthor = \"Editorial Team\"\ndate = 2023-05-01T00:00:00Z\nratio = 1.0\ntags = [\"go\", \"hugo\"]\ntitle = \"Patched\"\n\n[params]\n  migrated = true\n---", converted)
+
+	cfg.FrontMatterPatchFile = filepath.Join(t.TempDir(), "missing.json")
+	_, err = internal.NewFrontMatterConverter(cfg).ConvertFrontMatterMap(map[string]interface{}{"title": "Missing"})
+	assert.Error(t, err)
+}
+
+func TestFrontMatterConverterKeyMap(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	fmc := internal.NewFrontMatterConverter(cfg)
+
+	keyMap := fmc.KeyMap()
+	assert.Equal(t, "slug", keyMap["permalink"])
+	assert.Equal(t, "lastmod", keyMap["updated"])
+	assert.Equal(t, "images", keyMap["cover"])
+
+	keyMap["permalink"] = "url"
+	assert.Equal(t, "slug", fmc.KeyMap()["permalink"], "KeyMap must return a copy")
+
+	cfg.FlattenParams = false
+	assert.Equal(t, "params.author", internal.NewFrontMatterConverter(cfg).KeyMap()["author"])
+
+	cfg.ConversionDirection = "passthrough"
+	assert.Empty(t, internal.NewFrontMatterConverter(cfg).KeyMap())
+}
+
+func TestExportKeyMap(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.ConversionDirection = "hugo2hexo"
+	fmc := internal.NewFrontMatterConverter(cfg)
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format:   "yaml",
+			expected: "aliases: redirect_from\nauthor: author\ncategories: categories\ndate: date\ndescription: description\ndraft: published\nkeywords: keywords\nlastmod: updated\nslug: permalink\ntags: tags\ntitle: title\n",
+		},
+		{
+			format:   "toml",
+			expected: "aliases = \"redirect_from\"\nauthor = \"author\"\ncategories = \"categories\"\ndate = \"date\"\ndescription = \"description\"\ndraft = \"published\"\nkeywords = \"keywords\"\nlastmod = \"updated\"\nslug = \"permalink\"\ntags = \"tags\"\ntitle = \"title\"\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, internal.ExportKeyMap(fmc, tc.format, &buf))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+
+	err := internal.ExportKeyMap(fmc, "json", &bytes.Buffer{})
+	assert.EqualError(t, err, "unsupported key map format: json")
+}
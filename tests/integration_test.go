@@ -1,12 +1,22 @@
 package tests
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/pplmx/h2h/internal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -62,7 +72,7 @@ func TestConvertPosts(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			srcDir, dstDir := createTestEnvironment(t, tc.files)
 
-			err := internal.ConvertPosts(srcDir, dstDir, tc.config)
+			_, err := internal.ConvertPosts(srcDir, dstDir, tc.config)
 
 			if tc.expectError {
 				assert.Error(t, err)
@@ -88,7 +98,7 @@ func TestConvertLargeFile(t *testing.T) {
 	})
 
 	cfg := internal.NewDefaultConfig()
-	err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
 	assert.NoError(t, err, "ConvertPosts failed for large file")
 
 	verifyFileContent(t, dstDir, "large.md", "This is a large test post.")
@@ -103,12 +113,109 @@ func TestConvertNestedDirectories(t *testing.T) {
 	})
 
 	cfg := internal.NewDefaultConfig()
-	err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
 	assert.NoError(t, err, "ConvertPosts failed for nested directories")
 
 	verifyFileContent(t, filepath.Join(dstDir, "nested"), "nested.md", "This is a nested post.")
 }
 
+func TestConvertSkipHiddenFiles(t *testing.T) {
+	files := []struct{ name, content string }{
+		{name: "visible.md", content: createTestContent("Visible", "2023-05-01", nil, nil, "This is a visible post.")},
+		{name: ".hidden.md", content: createTestContent("Hidden", "2023-05-01", nil, nil, "This is a hidden post.")},
+		{name: ".git/ignored.md", content: "not front matter"},
+	}
+
+	t.Run("Skipped by default", func(t *testing.T) {
+		srcDir, dstDir := createTestEnvironment(t, files)
+		_, err := internal.ConvertPosts(srcDir, dstDir, internal.NewDefaultConfig())
+		require.NoError(t, err)
+
+		verifyFileContent(t, dstDir, "visible.md", "This is a visible post.")
+		assert.NoFileExists(t, filepath.Join(dstDir, ".hidden.md"))
+		assert.NoDirExists(t, filepath.Join(dstDir, ".git"))
+	})
+
+	t.Run("Opted out", func(t *testing.T) {
+		srcDir, dstDir := createTestEnvironment(t, files[:2])
+		cfg := internal.NewDefaultConfig()
+		cfg.SkipHiddenFiles = false
+		_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+		require.NoError(t, err)
+
+		verifyFileContent(t, dstDir, ".hidden.md", "This is a hidden post.")
+	})
+}
+
+func TestConvertIncludeExcludeGlobs(t *testing.T) {
+	names := []string{
+		"root.md",
+		"posts/a.md",
+		"posts/2023/05/b.md",
+		"posts/drafts/c.md",
+		"drafts/d.md",
+		"drafts/deep/nested/e.md",
+	}
+	files := make([]struct{ name, content string }, len(names))
+	for i, name := range names {
+		files[i] = struct{ name, content string }{name: name, content: createTestContent(name, "2023-05-01", nil, nil, "This is "+name)}
+	}
+
+	testCases := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{
+			name:     "No filters",
+			expected: names,
+		},
+		{
+			name:     "Single level include",
+			include:  []string{"posts/*.md"},
+			expected: []string{"posts/a.md"},
+		},
+		{
+			name:     "Recursive include",
+			include:  []string{"posts/**/*.md"},
+			expected: []string{"posts/a.md", "posts/2023/05/b.md", "posts/drafts/c.md"},
+		},
+		{
+			name:     "Recursive exclude",
+			exclude:  []string{"drafts/**"},
+			expected: []string{"root.md", "posts/a.md", "posts/2023/05/b.md", "posts/drafts/c.md"},
+		},
+		{
+			name:     "Exclude wins over overlapping include",
+			include:  []string{"**/*.md"},
+			exclude:  []string{"**/drafts/**", "posts/2023/**/b.md"},
+			expected: []string{"root.md", "posts/a.md"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srcDir, dstDir := createTestEnvironment(t, files)
+			cfg := internal.NewDefaultConfig()
+			cfg.IncludeGlobs = tc.include
+			cfg.ExcludeGlobs = tc.exclude
+			summary, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+			require.NoError(t, err)
+
+			assert.Equal(t, len(tc.expected), summary.SuccessCount)
+			for _, name := range tc.expected {
+				verifyFileContent(t, dstDir, name, "This is "+name)
+			}
+		})
+	}
+
+	cfg := internal.NewDefaultConfig()
+	cfg.ExcludeGlobs = []string{"posts/[a"}
+	_, err := internal.ConvertPosts(t.TempDir(), t.TempDir(), cfg)
+	assert.ErrorIs(t, err, doublestar.ErrBadPattern)
+}
+
 func TestConvertWithDifferentConcurrency(t *testing.T) {
 	files := make([]struct{ name, content string }, 10)
 	for i := 0; i < 10; i++ {
@@ -125,7 +232,7 @@ func TestConvertWithDifferentConcurrency(t *testing.T) {
 		t.Run(fmt.Sprintf("Concurrency%d", concurrency), func(t *testing.T) {
 			cfg := internal.NewDefaultConfig()
 			cfg.MaxConcurrency = concurrency
-			err := internal.ConvertPosts(srcDir, dstDir, cfg)
+			_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
 			assert.NoError(t, err, "ConvertPosts failed with concurrency %d", concurrency)
 
 			for i := 0; i < 10; i++ {
@@ -135,6 +242,788 @@ func TestConvertWithDifferentConcurrency(t *testing.T) {
 	}
 }
 
+func TestConvertWithAutoScaleWorkers(t *testing.T) {
+	files := make([]struct{ name, content string }, 20)
+	for i := range files {
+		files[i] = struct{ name, content string }{
+			name:    fmt.Sprintf("test%d.md", i),
+			content: createTestContent(fmt.Sprintf("Test Post %d", i), "2023-05-01", nil, nil, fmt.Sprintf("This is test post number %d.", i)),
+		}
+	}
+	srcDir, dstDir := createTestEnvironment(t, files)
+
+	cfg := internal.NewDefaultConfig()
+	cfg.AutoScaleWorkers = true
+	cfg.MemoryLimitMB = 1
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	for i := range files {
+		verifyFileContent(t, dstDir, fmt.Sprintf("test%d.md", i), fmt.Sprintf("This is test post number %d.", i))
+	}
+}
+
+func TestConvertFrontMatterDiff(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "diff.md", content: "---\ntitle: Diff\npermalink: diff-post\n---\nThis is a diff post."},
+	})
+
+	var calls int
+	var before, after map[string]interface{}
+	cfg := internal.NewDefaultConfig()
+	cfg.FrontMatterDiff = func(path string, b, a map[string]interface{}) {
+		calls++
+		assert.Equal(t, filepath.Join(srcDir, "diff.md"), path)
+		before, after = b, a
+	}
+
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, map[string]interface{}{"title": "Diff", "permalink": "diff-post"}, before)
+	assert.Equal(t, map[string]interface{}{"title": "Diff", "slug": "diff-post"}, after)
+}
+
+func TestConvertAutoDetectSourceFormat(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "yaml.md", content: "---\ntitle: YAML\nupdated: 2023-05-01\n---\nThis is a YAML post."},
+		{name: "toml.md", content: "+++\ntitle = \"TOML\"\nupdated = 2023-05-02\n+++\nThis is a TOML post."},
+	})
+
+	var logs bytes.Buffer
+	cfg := internal.NewDefaultConfig()
+	cfg.SourceFormat = "auto"
+	cfg.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	yamlPost, err := os.ReadFile(filepath.Join(dstDir, "yaml.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\nlastmod: 2023-05-01T00:00:00Z\ntitle: YAML\n---\nThis is a YAML post.", string(yamlPost))
+
+	tomlPost, err := os.ReadFile(filepath.Join(dstDir, "toml.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "+++\nlastmod = 2023-05-02\ntitle = \"TOML\"\n+++\nThis is a TOML post.", string(tomlPost))
+
+	assert.Contains(t, logs.String(), "file="+filepath.Join(srcDir, "yaml.md")+" format=yaml")
+	assert.Contains(t, logs.String(), "file="+filepath.Join(srcDir, "toml.md")+" format=toml")
+}
+
+func TestConvertMaxLineLength(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "long.md", content: "---\ntitle: Long\ndescription: " + strings.Repeat("word ", 30) + "\n---\nThis is a long post."},
+	})
+
+	var logs bytes.Buffer
+	cfg := internal.NewDefaultConfig()
+	cfg.MaxLineLength = 120
+	cfg.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	verifyFileContent(t, dstDir, "long.md", "This is a long post.")
+	assert.Contains(t, logs.String(), "level=WARN")
+	assert.Contains(t, logs.String(), "file="+filepath.Join(srcDir, "long.md"))
+	assert.Contains(t, logs.String(), "line=2 length=162")
+	assert.Equal(t, 1, strings.Count(logs.String(), "\n"), "only the description line is too long")
+}
+
+func TestConvertSplitLongPosts(t *testing.T) {
+	var body strings.Builder
+	for section := 1; section <= 3; section++ {
+		body.WriteString(fmt.Sprintf("## Section %d\n", section))
+		for line := 0; line < 9; line++ {
+			body.WriteString(fmt.Sprintf("Line %d of section %d.\n", line, section))
+		}
+	}
+
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "long.md", content: createTestContent("Long", "2023-05-01", nil, nil, body.String())},
+		{name: "short.md", content: createTestContent("Short", "2023-05-01", nil, nil, "This is a short post.")},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	cfg.SplitLongPosts = true
+	cfg.SplitAtLines = 10
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	verifyFileContent(t, dstDir, "short.md", "This is a short post.")
+	assert.NoFileExists(t, filepath.Join(dstDir, "long.md"))
+
+	for part := 1; part <= 3; part++ {
+		name := fmt.Sprintf("long-part-%d.md", part)
+		verifyFileContent(t, dstDir, name, fmt.Sprintf("## Section %d\n", part))
+
+		content, err := os.ReadFile(filepath.Join(dstDir, name))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), fmt.Sprintf("part: %d\n", part))
+		assert.Contains(t, string(content), "title: Long\n")
+	}
+
+	first, err := os.ReadFile(filepath.Join(dstDir, "long-part-1.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(first), "next: long-part-2\n")
+	assert.NotContains(t, string(first), "prev:")
+	assert.NotContains(t, string(first), "Section 2")
+}
+
+func TestConvertWithCaching(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "cached.md", content: "---\ntitle: Cached\ndate: 2023-05-01\nweight: 2\nratio: 1.0\ntags: [go]\n---\nThis is a cached post."},
+	})
+	cacheDir := t.TempDir()
+
+	cfg := internal.NewDefaultConfig()
+	cfg.TargetFormat = "toml"
+	cfg.EnableCaching = true
+	cfg.CacheDir = cacheDir
+
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+	first, err := os.ReadFile(filepath.Join(dstDir, "cached.md"))
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	_, err = internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+	second, err := os.ReadFile(filepath.Join(dstDir, "cached.md"))
+	require.NoError(t, err)
+	assert.Equal(t, string(first), string(second))
+	assert.Contains(t, string(second), "date = 2023-05-01T00:00:00Z\n")
+	assert.Contains(t, string(second), "ratio = 1.0\n")
+	assert.Contains(t, string(second), "weight = 2\n")
+
+	cfg.ConversionDirection = "hugo2hexo"
+	_, err = internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+	entries, err = os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "changing the key map should invalidate the cache")
+}
+
+func TestConvertOmitUnchanged(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{
+			name:    "unchanged.md",
+			content: "---\ndescription: Nothing to rename\ntitle: Unchanged Post\n---\nThis is an unchanged post.",
+		},
+		{
+			name:    "changed.md",
+			content: "---\ntitle: Changed Post\nupdated: 2023-05-02\n---\nThis is a changed post.",
+		},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	cfg.OmitFrontMatterIfUnchanged = true
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, filepath.Join(dstDir, "unchanged.md"))
+	verifyFileContent(t, dstDir, "changed.md", "This is a changed post.")
+}
+
+func TestConvertCategoriesToSections(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{
+			name:    "single.md",
+			content: createTestContent("Single", "2023-05-01", nil, []string{"go"}, "This is a single category post."),
+		},
+		{
+			name:    "multiple.md",
+			content: createTestContent("Multiple", "2023-05-02", nil, []string{"web", "hugo"}, "This is a multiple category post."),
+		},
+		{
+			name:    "none.md",
+			content: createTestContent("None", "2023-05-03", nil, nil, "This is an uncategorised post."),
+		},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	cfg.ConvertCategoriesToSections = true
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	verifyFileContent(t, filepath.Join(dstDir, "go"), "single.md", "This is a single category post.")
+	verifyFileContent(t, filepath.Join(dstDir, "web"), "multiple.md", "This is a multiple category post.")
+	verifyFileContent(t, dstDir, "none.md", "This is an uncategorised post.")
+
+	single, err := os.ReadFile(filepath.Join(dstDir, "go", "single.md"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(single), "categories")
+
+	multiple, err := os.ReadFile(filepath.Join(dstDir, "web", "multiple.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(multiple), "categories:\n    - hugo")
+}
+
+func TestConvertIgnoreErrors(t *testing.T) {
+	invalid := "# Invalid Post\nThis is an invalid post without front matter."
+
+	testCases := []struct {
+		name     string
+		annotate bool
+		expected string
+	}{
+		{
+			name:     "Copied unchanged",
+			expected: invalid,
+		},
+		{
+			name:     "Annotated",
+			annotate: true,
+			expected: "<!-- h2h conversion warning: parsing content: invalid hexo/hugo markdown format -->\n" + invalid,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+				{name: "invalid.md", content: invalid},
+			})
+
+			cfg := internal.NewDefaultConfig()
+			cfg.IgnoreErrors = true
+			cfg.AnnotateErrors = tc.annotate
+			_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+			require.NoError(t, err)
+
+			content, err := os.ReadFile(filepath.Join(dstDir, "invalid.md"))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(content))
+		})
+	}
+}
+
+func TestConvertForkOnError(t *testing.T) {
+	source := "---\ntitle: Fork\nupdated: 2023-05-02\n---\nThis post has no author."
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "fork.md", content: source},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	cfg.RequireFields = []string{"author"}
+	cfg.IgnoreErrors = true
+	cfg.ForkOnError = true
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	converted, err := os.ReadFile(filepath.Join(dstDir, "fork.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(converted), "lastmod:")
+	assert.NotContains(t, string(converted), "updated:")
+	assert.Contains(t, string(converted), "This post has no author.")
+
+	original, err := os.ReadFile(filepath.Join(dstDir, "fork.md.original"))
+	require.NoError(t, err)
+	assert.Equal(t, source, string(original))
+}
+
+func TestConvertLogLevels(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "valid.md", content: createTestContent("Valid", "2023-05-01", nil, nil, "This is a valid post.")},
+		{name: "invalid.md", content: "# Invalid Post\nThis is an invalid post without front matter."},
+	})
+
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelWarn, slog.LevelError} {
+		t.Run(level.String(), func(t *testing.T) {
+			var logs bytes.Buffer
+			cfg := internal.NewDefaultConfig()
+			cfg.IgnoreErrors = true
+			cfg.Logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: level}))
+			_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+			require.NoError(t, err)
+
+			debug := "level=DEBUG msg=\"reading source file\" file=" + filepath.Join(srcDir, "valid.md")
+			warn := "level=WARN msg=\"ignoring conversion error\" file=" + filepath.Join(srcDir, "invalid.md")
+			assert.Equal(t, level <= slog.LevelDebug, strings.Contains(logs.String(), debug))
+			assert.Equal(t, level <= slog.LevelWarn, strings.Contains(logs.String(), warn))
+		})
+	}
+}
+
+func TestConvertOnFileStart(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "a.md", content: createTestContent("A", "2023-05-01", nil, nil, "This is post a.")},
+		{name: "nested/b.md", content: createTestContent("B", "2023-05-02", nil, nil, "This is post b.")},
+		{name: "invalid.md", content: "# Invalid Post\nThis is an invalid post without front matter."},
+	})
+
+	var mu sync.Mutex
+	var started []string
+	cfg := internal.NewDefaultConfig()
+	cfg.OnFileStart = func(relPath string) {
+		mu.Lock()
+		defer mu.Unlock()
+		started = append(started, relPath)
+	}
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.Error(t, err)
+	assert.ElementsMatch(t, []string{"a.md", filepath.Join("nested", "b.md"), "invalid.md"}, started)
+}
+
+func TestConvertFrontMatterOnlyJSON(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "posts/post.md", content: "+++\ntitle = \"Split\"\nupdated = 2023-05-02\n+++\n\nThis is the body.\n"},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	cfg.SourceFormat = "auto"
+	cfg.TargetFormat = "frontmatter-only-json"
+	cfg.JSONIndent = 0
+	summary, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.SuccessCount)
+
+	body, err := os.ReadFile(filepath.Join(dstDir, "posts", "post.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "This is the body.\n", string(body))
+
+	frontMatter, err := os.ReadFile(filepath.Join(dstDir, "posts", "post.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\"lastmod\":\"2023-05-02T00:00:00Z\",\"title\":\"Split\"}\n", string(frontMatter))
+}
+
+func TestConvertPostSortKey(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "b.md", content: createTestContent("B", "2023-05-03", nil, nil, "This is post b.")},
+		{name: "a.md", content: createTestContent("A", "2023-05-02", nil, nil, "This is post a.")},
+		{name: "c.md", content: createTestContent("C", "2023-05-01", nil, nil, "This is post c.")},
+		{name: "undated.md", content: "---\ntitle: Undated\n---\nThis is an undated post."},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	cfg.PostSortKey = "date"
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	verifyFileContent(t, dstDir, "0001-c.md", "This is post c.")
+	verifyFileContent(t, dstDir, "0002-a.md", "This is post a.")
+	verifyFileContent(t, dstDir, "0003-b.md", "This is post b.")
+	verifyFileContent(t, dstDir, "0004-undated.md", "This is an undated post.")
+}
+
+func TestConvertPostSortKeyManifest(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "a.md", content: createTestContent("A", "2023-05-02", nil, nil, "This is post a.")},
+		{name: "b/b.md", content: createTestContent("B", "2023-05-01", nil, nil, "This is post b.")},
+	})
+	manifestFile := filepath.Join(t.TempDir(), "manifest.json")
+
+	cfg := internal.NewDefaultConfig()
+	cfg.PostSortKey = "date"
+	cfg.ManifestFile = manifestFile
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(manifestFile)
+	require.NoError(t, err)
+	var entries []struct {
+		Source       string   `json:"source"`
+		Destinations []string `json:"destinations"`
+	}
+	require.NoError(t, json.Unmarshal(data, &entries))
+	require.Len(t, entries, 2)
+	assert.Equal(t, []string{"0002-a.md"}, entries[0].Destinations)
+	assert.Equal(t, []string{"b/0001-b.md"}, entries[1].Destinations)
+	for _, entry := range entries {
+		assert.FileExists(t, filepath.Join(dstDir, entry.Destinations[0]))
+	}
+}
+
+func TestConvertPostSortKeyOutputs(t *testing.T) {
+	a := createTestContent("A", "2023-05-02", nil, nil, "This is post a.")
+	b := createTestContent("B", "2023-05-01", nil, nil, "This is post b.")
+	invalid := "# Invalid Post\nThis is an invalid post without front matter."
+
+	testCases := []struct {
+		name     string
+		files    []struct{ name, content string }
+		modify   func(cfg *internal.Config)
+		expected []string
+	}{
+		{
+			name:     "Front matter only JSON",
+			files:    []struct{ name, content string }{{"a.md", a}, {"b.md", b}},
+			modify:   func(cfg *internal.Config) { cfg.TargetFormat = "frontmatter-only-json" },
+			expected: []string{".h2h-sorted", "0001-b.json", "0001-b.md", "0002-a.json", "0002-a.md"},
+		},
+		{
+			name: "Custom separator",
+			files: []struct{ name, content string }{
+				{"a.md", strings.ReplaceAll(a, "---", "===")},
+				{"b.md", strings.ReplaceAll(b, "---", "===")},
+			},
+			modify:   func(cfg *internal.Config) { cfg.CustomFrontMatterSeparator = "===" },
+			expected: []string{".h2h-sorted", "0001-b.md", "0002-a.md"},
+		},
+		{
+			name:  "Annotated fallback sorts last",
+			files: []struct{ name, content string }{{"a.md", a}, {"b.md", b}, {"invalid.md", invalid}},
+			modify: func(cfg *internal.Config) {
+				cfg.IgnoreErrors = true
+				cfg.AnnotateErrors = true
+			},
+			expected: []string{".h2h-sorted", "0001-b.md", "0002-a.md", "0003-invalid.md"},
+		},
+		{
+			name:  "Original copy keeps its post's prefix",
+			files: []struct{ name, content string }{{"a.md", a}, {"b.md", b}, {"invalid.md", invalid}},
+			modify: func(cfg *internal.Config) {
+				cfg.IgnoreErrors = true
+				cfg.ForkOnError = true
+			},
+			expected: []string{".h2h-sorted", "0001-b.md", "0002-a.md", "0003-invalid.md", "0003-invalid.md.original"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srcDir, dstDir := createTestEnvironment(t, tc.files)
+			cfg := internal.NewDefaultConfig()
+			cfg.PostSortKey = "date"
+			tc.modify(cfg)
+			_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, dirNames(t, dstDir))
+		})
+	}
+}
+
+func TestConvertPostSortKeyRerun(t *testing.T) {
+	dir, _ := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "a.md", content: createTestContent("A", "2023-05-02", nil, nil, "This is post a.")},
+		{name: "2019-recap.md", content: createTestContent("Recap", "2023-05-01", nil, nil, "This is the recap.")},
+		{name: "2023-05-03-c.md", content: createTestContent("C", "2023-05-03", nil, nil, "This is post c.")},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	cfg.ConversionDirection = "passthrough"
+	cfg.PostSortKey = "date"
+	_, err := internal.ConvertPosts(dir, dir, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".h2h-sorted", "0001-2019-recap.md", "0002-a.md", "0003-2023-05-03-c.md"}, dirNames(t, dir))
+
+	// moving a post to the front renumbers the others instead of stacking
+	// prefixes, and leaves the year in 2019-recap.md alone
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0002-a.md"), []byte(createTestContent("A", "2023-04-30", nil, nil, "This is post a.")), 0644))
+	_, err = internal.ConvertPosts(dir, dir, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".h2h-sorted", "0001-a.md", "0002-2019-recap.md", "0003-2023-05-03-c.md"}, dirNames(t, dir))
+}
+
+// dirNames returns the names of the entries of dir
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestConvertOPAPolicy(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "approved.md", content: createTestContent("Approved", "2023-05-01", nil, []string{"go"}, "This is an approved post.")},
+		{name: "rejected.md", content: createTestContent("Rejected", "2023-05-02", nil, []string{"gossip"}, "This is a rejected post.")},
+	})
+
+	policyFile := filepath.Join(t.TempDir(), "policy.rego")
+	err := os.WriteFile(policyFile, []byte(`package h2h
+
+approved := {"go", "web"}
+
+deny contains msg if {
+	some category in input.categories
+	not approved[category]
+	msg := sprintf("category %q is not approved", [category])
+}
+`), 0644)
+	require.NoError(t, err)
+
+	cfg := internal.NewDefaultConfig()
+	cfg.OPAPolicyFile = policyFile
+	_, err = internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.Error(t, err)
+
+	verifyFileContent(t, dstDir, "approved.md", "This is an approved post.")
+	assert.NoFileExists(t, filepath.Join(dstDir, "rejected.md"))
+
+	cfg.OPAPolicyFile = filepath.Join(t.TempDir(), "missing.rego")
+	_, err = internal.ConvertPosts(srcDir, t.TempDir(), cfg)
+	assert.Error(t, err)
+}
+
+func TestConvertFileOPAPolicyCancel(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "post.md", content: createTestContent("Post", "2023-05-01", nil, nil, "This is a post.")},
+	})
+
+	policyFile := filepath.Join(t.TempDir(), "policy.rego")
+	err := os.WriteFile(policyFile, []byte(`package h2h
+
+deny contains msg if {
+	some i in numbers.range(1, 100000)
+	some j in numbers.range(1, 100000)
+	i * j < 0
+	msg := "unreachable"
+}
+`), 0644)
+	require.NoError(t, err)
+
+	cfg := internal.NewDefaultConfig()
+	cfg.OPAPolicyFile = policyFile
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = internal.ConvertFile(ctx, internal.NewMarkdownConverter(cfg), filepath.Join(srcDir, "post.md"), filepath.Join(dstDir, "post.md"))
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second, "the policy check stops when the context is done")
+	assert.NoFileExists(t, filepath.Join(dstDir, "post.md"))
+}
+
+func TestConvertInPlace(t *testing.T) {
+	srcDir, _ := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "post1.md", content: createTestContent("Post 1", "2023-05-01", []string{"go"}, nil, "This is post 1.")},
+		{name: "nested/post2.md", content: "---\ntitle: Post 2\nupdated: 2023-05-02\n---\nThis is post 2."},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	_, err := internal.ConvertPosts(srcDir, filepath.Join(srcDir, "nested", ".."), cfg)
+	require.NoError(t, err)
+
+	verifyFileContent(t, srcDir, "post1.md", "This is post 1.")
+	post2, err := os.ReadFile(filepath.Join(srcDir, "nested", "post2.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\nlastmod: 2023-05-02T00:00:00Z\ntitle: Post 2\n---\nThis is post 2.", string(post2))
+
+	for _, dir := range []string{srcDir, filepath.Join(srcDir, "nested")} {
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		for _, entry := range entries {
+			assert.NotContains(t, entry.Name(), ".tmp", "temporary files should be renamed or removed")
+		}
+	}
+}
+
+func TestConvertBackupAndRestore(t *testing.T) {
+	original := "---\ntitle: Post 2\nupdated: 2023-05-02\n---\nThis is post 2."
+	srcDir, _ := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "nested/post2.md", content: original},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	cfg.BackupSuffix = ".bak"
+	_, err := internal.ConvertPosts(srcDir, srcDir, cfg)
+	require.NoError(t, err)
+
+	backup, err := os.ReadFile(filepath.Join(srcDir, "nested", "post2.md.bak"))
+	require.NoError(t, err)
+	assert.Equal(t, original, string(backup))
+	verifyFileContent(t, filepath.Join(srcDir, "nested"), "post2.md", "lastmod: 2023-05-02T00:00:00Z")
+
+	backups, err := internal.FindBackups(srcDir, ".bak")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(srcDir, "nested", "post2.md.bak")}, backups)
+
+	restored, err := internal.RestoreBackups(srcDir, ".bak")
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	assert.NoFileExists(t, filepath.Join(srcDir, "nested", "post2.md.bak"))
+	verifyFileContent(t, filepath.Join(srcDir, "nested"), "post2.md", original)
+
+	dstDir := t.TempDir()
+	_, err = internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dstDir, "nested", "post2.md.bak"), "new destination files need no backup")
+}
+
+func TestConvertGenerateTaxonomyFiles(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "post1.md", content: createTestContent("Post 1", "2023-05-01", []string{"Go", "Web Dev"}, []string{"Programming"}, "This is post 1.")},
+		{name: "post2.md", content: createTestContent("Post 2", "2023-05-02", []string{"Go"}, nil, "This is post 2.")},
+	})
+
+	existing := filepath.Join(dstDir, "tags", "go", "_index.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(existing), 0755))
+	require.NoError(t, os.WriteFile(existing, []byte("---\ntitle: Custom\n---\n"), 0644))
+
+	cfg := internal.NewDefaultConfig()
+	cfg.GenerateTaxonomyFiles = true
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	verifyFileContent(t, filepath.Join(dstDir, "tags", "web-dev"), "_index.md", "---\ntitle: Web Dev\n---\n")
+	verifyFileContent(t, filepath.Join(dstDir, "categories", "programming"), "_index.md", "---\ntitle: Programming\n---\n")
+	verifyFileContent(t, filepath.Join(dstDir, "tags", "go"), "_index.md", "---\ntitle: Custom\n---\n")
+}
+
+func TestConvertGenerateTaxonomyFilesWritePath(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "post1.md", content: createTestContent("Post 1", "2023-05-01", []string{"Go"}, nil, "This is post 1.")},
+		{name: "post2.md", content: createTestContent("Post 2", "2023-05-02", []string{"Go"}, nil, "This is post 2.")},
+	})
+	older := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	newer := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(srcDir, "post1.md"), older, older))
+	require.NoError(t, os.Chtimes(filepath.Join(srcDir, "post2.md"), newer, newer))
+
+	termDir := filepath.Join(dstDir, "tags", "go")
+	require.NoError(t, os.MkdirAll(termDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(termDir, "_index.json"), []byte("{}"), 0644))
+
+	cfg := internal.NewDefaultConfig()
+	cfg.GenerateTaxonomyFiles = true
+	cfg.TargetFormat = "frontmatter-only-json"
+	cfg.BackupSuffix = ".bak"
+	cfg.TemporaryDirectory = t.TempDir()
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	body, err := os.ReadFile(filepath.Join(termDir, "_index.md"))
+	require.NoError(t, err)
+	assert.Empty(t, body)
+	frontMatter, err := os.ReadFile(filepath.Join(termDir, "_index.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title": "Go"}`, string(frontMatter))
+	backup, err := os.ReadFile(filepath.Join(termDir, "_index.json.bak"))
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(backup))
+
+	for _, name := range []string{"_index.md", "_index.json"} {
+		info, err := os.Stat(filepath.Join(termDir, name))
+		require.NoError(t, err)
+		assert.True(t, info.ModTime().Equal(newer), "%s takes the newest post modification time", name)
+	}
+}
+
+func TestConvertSummary(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "post1.md", content: createTestContent("Post 1", "2023-05-01", nil, nil, "This is post 1.")},
+		{name: "post2.md", content: createTestContent("Post 2", "2023-05-02", nil, nil, "This is post 2.")},
+		{name: "unchanged.md", content: "---\ndescription: Nothing to rename\ntitle: Unchanged Post\n---\nThis is an unchanged post."},
+		{name: "invalid.md", content: "# Invalid Post\nThis is an invalid post without front matter."},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	cfg.OmitFrontMatterIfUnchanged = true
+	summary, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.EqualError(t, err, "encountered 1 errors during conversion")
+
+	assert.Equal(t, 2, summary.SuccessCount)
+	assert.Equal(t, 1, summary.SkippedCount)
+	require.Len(t, summary.Errors, 1)
+	assert.Equal(t, filepath.Join(srcDir, "invalid.md"), summary.Errors[0].SourceFile)
+	assert.Positive(t, summary.Duration)
+}
+
+func TestConvertErrorList(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "post.md", content: createTestContent("Post", "2023-05-01", nil, nil, "This is a post.")},
+		{name: "b.md", content: "# Invalid Post\nThis is an invalid post without front matter."},
+		{name: "a.md", content: "# Invalid Post\nThis is another invalid post."},
+	})
+
+	summary, err := internal.ConvertPosts(srcDir, dstDir, internal.NewDefaultConfig())
+	require.EqualError(t, err, "encountered 2 errors during conversion")
+
+	var errList *internal.ConversionErrorList
+	require.ErrorAs(t, err, &errList)
+	require.Len(t, errList.Errors(), 2)
+	assert.Equal(t, summary.Errors, errList.Errors())
+	assert.Equal(t, filepath.Join(srcDir, "a.md"), errList.Errors()[0].SourceFile)
+	assert.Equal(t, filepath.Join(srcDir, "b.md"), errList.Errors()[1].SourceFile)
+
+	var convErr *internal.ConversionError
+	require.ErrorAs(t, err, &convErr)
+	assert.Equal(t, filepath.Join(srcDir, "a.md"), convErr.SourceFile)
+	assert.ErrorContains(t, errors.Unwrap(convErr), "invalid hexo/hugo markdown format")
+}
+
+func TestConvertDryRun(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "renamed.md", content: "---\ntitle: Renamed\nupdated: 2023-05-02\n---\nThis is a renamed post."},
+		{name: "unchanged.md", content: "---\ndescription: Nothing to rename\ntitle: Unchanged Post\n---\nThis is an unchanged post."},
+		{name: "invalid.md", content: "# Invalid Post\nThis is an invalid post without front matter."},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	cfg.DryRun = true
+	cfg.OmitFrontMatterIfUnchanged = true
+	summary, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.Error(t, err, "format errors should still be reported in dry-run mode")
+
+	require.Len(t, summary.Planned, 1)
+	assert.Equal(t, filepath.Join(srcDir, "renamed.md"), summary.Planned[0].SourcePath)
+	assert.Equal(t, filepath.Join(dstDir, "renamed.md"), summary.Planned[0].DestinationPath)
+	assert.Equal(t, map[string]string{"updated": "lastmod"}, summary.Planned[0].RenamedKeys)
+	assert.Equal(t, []string{filepath.Join(srcDir, "unchanged.md")}, summary.Skipped)
+	assert.Len(t, summary.Errors, 1)
+
+	entries, err := os.ReadDir(dstDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestEncryptAndDecryptFields(t *testing.T) {
+	const key = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+	srcDir, encryptedDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "secret.md", content: "---\ntitle: Secret\nemail: me@example.com\n---\nThis is a secret post."},
+	})
+	decryptedDir := t.TempDir()
+
+	cfg := internal.NewDefaultConfig()
+	cfg.FrontMatterEncryptFields = []string{"email"}
+	cfg.EncryptionKey = key
+	_, err := internal.ConvertPosts(srcDir, encryptedDir, cfg)
+	require.NoError(t, err)
+
+	encrypted, err := os.ReadFile(filepath.Join(encryptedDir, "secret.md"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "me@example.com")
+	assert.Contains(t, string(encrypted), "title: Secret")
+
+	require.NoError(t, internal.DecryptPosts(encryptedDir, decryptedDir, cfg))
+	decrypted, err := os.ReadFile(filepath.Join(decryptedDir, "secret.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(decrypted), "email: me@example.com")
+	assert.Contains(t, string(decrypted), "This is a secret post.")
+
+	cfg.EncryptionKey = "abcd"
+	assert.ErrorContains(t, internal.DecryptPosts(encryptedDir, decryptedDir, cfg), "encryption key must be 32 bytes")
+}
+
+func TestDecryptFieldsCustomSeparator(t *testing.T) {
+	srcDir, encryptedDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "secret.md", content: "===\ntitle: Secret\nemail: me@example.com\n===\nThis is a secret post."},
+	})
+	decryptedDir := t.TempDir()
+
+	cfg := internal.NewDefaultConfig()
+	cfg.CustomFrontMatterSeparator = "==="
+	cfg.FrontMatterEncryptFields = []string{"email"}
+	cfg.EncryptionKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	_, err := internal.ConvertPosts(srcDir, encryptedDir, cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, internal.DecryptPosts(encryptedDir, decryptedDir, cfg))
+	decrypted, err := os.ReadFile(filepath.Join(decryptedDir, "secret.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "===\nemail: me@example.com\ntitle: Secret\n===\nThis is a secret post.", string(decrypted))
+
+	entries, err := os.ReadDir(decryptedDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files are left behind")
+}
+
 func BenchmarkConvertPosts(b *testing.B) {
 	files := make([]struct{ name, content string }, 10)
 	for i := 0; i < 10; i++ {
@@ -150,7 +1039,7 @@ func BenchmarkConvertPosts(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		err := internal.ConvertPosts(srcDir, dstDir, cfg)
+		_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
 		if err != nil {
 			b.Fatalf("ConvertPosts failed: %v", err)
 		}
@@ -201,3 +1090,480 @@ func verifyFileContent(t *testing.T, dir, name, expectedContent string) {
 	assert.Equal(t, 2, strings.Count(string(content), "---"), "Expected 2 '---' separators in %s", name)
 	assert.Contains(t, string(content), expectedContent, "Converted file %s does not contain expected content", name)
 }
+
+func BenchmarkConvertOutputBufferSize(b *testing.B) {
+	body := strings.Repeat("This is a line of a ten megabyte benchmark post.\n", 10<<20/50)
+	srcDir, dstDir := createTestEnvironment(b, []struct{ name, content string }{
+		{name: "huge.md", content: createTestContent("Huge Post", "2023-05-01", nil, nil, body)},
+	})
+
+	for _, size := range []int{0, 64 << 10, 256 << 10} {
+		b.Run(fmt.Sprintf("Buffer%dKB", size>>10), func(b *testing.B) {
+			cfg := internal.NewDefaultConfig()
+			cfg.OutputBufferSize = size
+			for i := 0; i < b.N; i++ {
+				if _, err := internal.ConvertPosts(srcDir, dstDir, cfg); err != nil {
+					b.Fatalf("ConvertPosts failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertFile(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "single.md", content: createTestContent("Single", "2023-05-01", nil, nil, "This is a single post.")},
+	})
+	srcPath := filepath.Join(srcDir, "single.md")
+	mc := internal.NewMarkdownConverter(internal.NewDefaultConfig())
+
+	require.NoError(t, internal.ConvertFile(context.Background(), mc, srcPath, filepath.Join(dstDir, "out", "single.md")))
+	verifyFileContent(t, filepath.Join(dstDir, "out"), "single.md", "This is a single post.")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := internal.ConvertFile(ctx, mc, srcPath, filepath.Join(dstDir, "cancelled.md"))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoFileExists(t, filepath.Join(dstDir, "cancelled.md"))
+
+	err = internal.ConvertFile(context.Background(), mc, filepath.Join(srcDir, "missing.md"), filepath.Join(dstDir, "missing.md"))
+	var convErr *internal.ConversionError
+	require.ErrorAs(t, err, &convErr)
+	assert.Equal(t, filepath.Join(srcDir, "missing.md"), convErr.SourceFile)
+	assert.ErrorIs(t, convErr, os.ErrNotExist)
+}
+
+func TestConvertSingleFile(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "single.md", content: createTestContent("Single", "2023-05-01", nil, nil, "This is a single post.")},
+		{name: "invalid.md", content: "# Invalid Post\nThis is an invalid post without front matter."},
+	})
+	srcPath := filepath.Join(srcDir, "single.md")
+	dstPath := filepath.Join(dstDir, "single.md")
+
+	cfg := internal.NewDefaultConfig()
+	cfg.DryRun = true
+	summary, err := internal.ConvertSingleFile(context.Background(), internal.NewMarkdownConverter(cfg), srcPath, dstPath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.SuccessCount)
+	assert.Equal(t, []internal.PlannedWrite{{SourcePath: srcPath, DestinationPath: dstPath, RenamedKeys: map[string]string{}}}, summary.Planned)
+	assert.NoFileExists(t, dstPath)
+
+	cfg.DryRun = false
+	summary, err = internal.ConvertSingleFile(context.Background(), internal.NewMarkdownConverter(cfg), srcPath, dstPath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.SuccessCount)
+	assert.Empty(t, summary.Planned)
+	verifyFileContent(t, dstDir, "single.md", "This is a single post.")
+
+	invalidPath := filepath.Join(srcDir, "invalid.md")
+	summary, err = internal.ConvertSingleFile(context.Background(), internal.NewMarkdownConverter(cfg), invalidPath, filepath.Join(dstDir, "invalid.md"))
+	require.EqualError(t, err, "encountered 1 errors during conversion")
+	require.Len(t, summary.Errors, 1)
+	assert.Equal(t, invalidPath, summary.Errors[0].SourceFile)
+}
+func TestConvertOpenMetrics(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "post1.md", content: createTestContent("Post 1", "2023-05-01", nil, nil, "This is post 1.")},
+		{name: "post2.md", content: createTestContent("Post 2", "2023-05-02", nil, nil, "This is post 2.")},
+		{name: "invalid.md", content: "# Invalid Post\nThis is an invalid post without front matter."},
+	})
+	metricsFile := filepath.Join(t.TempDir(), "metrics.txt")
+
+	cfg := internal.NewDefaultConfig()
+	cfg.EnableOpenMetrics = true
+	cfg.OpenMetricsFile = metricsFile
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.Error(t, err)
+
+	metrics, err := os.ReadFile(metricsFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(metrics), "h2h_files_converted_total 2\n")
+	assert.Contains(t, string(metrics), "h2h_files_failed_total 1\n")
+	assert.Contains(t, string(metrics), "h2h_files_skipped_total 0\n")
+	assert.Contains(t, string(metrics), "h2h_file_conversion_seconds_bucket{le=\"+Inf\"} 3\n")
+	assert.Contains(t, string(metrics), "h2h_file_conversion_seconds_count 3\n")
+	assert.True(t, strings.HasSuffix(string(metrics), "# EOF\n"))
+}
+
+func TestConvertAtomicWrites(t *testing.T) {
+	for _, atomic := range []bool{true, false} {
+		t.Run(fmt.Sprintf("Atomic %v", atomic), func(t *testing.T) {
+			srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+				{name: "ok.md", content: createTestContent("OK", "2023-05-01", nil, nil, "This is a fine post.")},
+				{name: "blocked.md", content: createTestContent("Blocked", "2023-05-02", nil, nil, "This is a blocked post.")},
+			})
+			// a directory in the way makes writing blocked.md fail
+			require.NoError(t, os.MkdirAll(filepath.Join(dstDir, "blocked.md"), 0755))
+
+			cfg := internal.NewDefaultConfig()
+			cfg.AtomicWrites = atomic
+			summary, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+			require.Error(t, err)
+			require.Len(t, summary.Errors, 1)
+
+			verifyFileContent(t, dstDir, "ok.md", "This is a fine post.")
+			entries, err := os.ReadDir(dstDir)
+			require.NoError(t, err)
+			names := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			assert.ElementsMatch(t, []string{"ok.md", "blocked.md"}, names, "no temporary files should be left behind")
+		})
+	}
+}
+
+func TestConvertPreserveModTime(t *testing.T) {
+	for _, preserve := range []bool{true, false} {
+		t.Run(fmt.Sprintf("Preserve %v", preserve), func(t *testing.T) {
+			srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+				{name: "post.md", content: createTestContent("Post", "2023-05-01", nil, nil, "This is a post.")},
+			})
+			modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+			require.NoError(t, os.Chtimes(filepath.Join(srcDir, "post.md"), modTime, modTime))
+
+			cfg := internal.NewDefaultConfig()
+			cfg.PreserveModTime = preserve
+			_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+			require.NoError(t, err)
+
+			info, err := os.Stat(filepath.Join(dstDir, "post.md"))
+			require.NoError(t, err)
+			assert.Equal(t, preserve, info.ModTime().Equal(modTime))
+		})
+	}
+}
+
+func TestConvertSkipExisting(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		t.Run(fmt.Sprintf("DryRun %v", dryRun), func(t *testing.T) {
+			srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+				{name: "new.md", content: createTestContent("New", "2023-05-01", nil, nil, "This is a new post.")},
+				{name: "existing.md", content: createTestContent("Existing", "2023-05-02", nil, nil, "This is an updated post.")},
+			})
+			require.NoError(t, os.WriteFile(filepath.Join(dstDir, "existing.md"), []byte("already converted"), 0644))
+
+			cfg := internal.NewDefaultConfig()
+			cfg.SkipExisting = true
+			cfg.DryRun = dryRun
+			summary, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+			require.NoError(t, err)
+
+			assert.Equal(t, 1, summary.SuccessCount)
+			assert.Equal(t, 1, summary.SkippedCount)
+			assert.Equal(t, []string{filepath.Join(srcDir, "existing.md")}, summary.Skipped)
+
+			existing, err := os.ReadFile(filepath.Join(dstDir, "existing.md"))
+			require.NoError(t, err)
+			assert.Equal(t, "already converted", string(existing))
+			if dryRun {
+				assert.NoFileExists(t, filepath.Join(dstDir, "new.md"))
+			} else {
+				verifyFileContent(t, dstDir, "new.md", "This is a new post.")
+			}
+		})
+	}
+}
+
+func TestConvertManifest(t *testing.T) {
+	post := createTestContent("Post", "2023-05-01", nil, nil, "This is a post.")
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "b/post.md", content: post},
+		{name: "a.md", content: createTestContent("A", "2023-05-02", nil, nil, "This is post a.")},
+		{name: "invalid.md", content: "# Invalid Post\nThis is an invalid post without front matter."},
+	})
+	manifestFile := filepath.Join(t.TempDir(), "manifest.json")
+
+	cfg := internal.NewDefaultConfig()
+	cfg.ManifestFile = manifestFile
+	cfg.ComputeChecksum = true
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.Error(t, err)
+
+	data, err := os.ReadFile(manifestFile)
+	require.NoError(t, err)
+	var entries []struct {
+		Source       string   `json:"source"`
+		Destinations []string `json:"destinations"`
+		SourceSHA256 string   `json:"source_sha256"`
+	}
+	require.NoError(t, json.Unmarshal(data, &entries))
+	require.Len(t, entries, 2, "failed files have no entry")
+	assert.Equal(t, "a.md", entries[0].Source)
+	assert.Equal(t, "b/post.md", entries[1].Source)
+	assert.Equal(t, []string{"b/post.md"}, entries[1].Destinations)
+	sum := sha256.Sum256([]byte(post))
+	assert.Equal(t, hex.EncodeToString(sum[:]), entries[1].SourceSHA256)
+
+	cfg.ComputeChecksum = false
+	_, err = internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.Error(t, err)
+	data, err = os.ReadFile(manifestFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "source_sha256")
+}
+
+func TestConvertWriteAtomicManifest(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "post.md", content: createTestContent("Post", "2023-05-01", nil, nil, "This is a post.")},
+	})
+	manifestFile := filepath.Join(t.TempDir(), "manifest.json")
+
+	cfg := internal.NewDefaultConfig()
+	cfg.ManifestFile = manifestFile
+	cfg.WriteAtomicManifest = true
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+	previous, err := os.ReadFile(manifestFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(previous), `"post.md"`)
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "invalid.md"), []byte("# Invalid Post\nNo front matter."), 0644))
+	_, err = internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.Error(t, err)
+
+	data, err := os.ReadFile(manifestFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(previous), string(data), "a failed run leaves the previous manifest in place")
+}
+
+func TestConvertJSONLogs(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "invalid.md", content: "# Invalid Post\nThis is an invalid post without front matter."},
+	})
+
+	var logs bytes.Buffer
+	cfg := internal.NewDefaultConfig()
+	cfg.IgnoreErrors = true
+	cfg.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "ignoring conversion error", record["msg"])
+	assert.Contains(t, record, "time")
+	assert.Equal(t, filepath.Join(srcDir, "invalid.md"), record["file"])
+	assert.Contains(t, record["error"], "invalid hexo/hugo markdown format")
+}
+
+func TestRotatingFile(t *testing.T) {
+	chunk := func(c byte) []byte { return bytes.Repeat([]byte{c}, 600*1024) }
+
+	t.Run("Keeps backups", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "h2h.log")
+		require.NoError(t, os.WriteFile(path, chunk('a'), 0644))
+
+		f, err := internal.OpenRotatingFile(path, 1, 2)
+		require.NoError(t, err)
+		for _, c := range []byte("bcd") {
+			_, err := f.Write(chunk(c))
+			require.NoError(t, err)
+		}
+		require.NoError(t, f.Close())
+
+		for name, c := range map[string]byte{"h2h.log": 'd', "h2h.log.1": 'c', "h2h.log.2": 'b'} {
+			content, err := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+			require.NoError(t, err)
+			assert.Equal(t, chunk(c), content, name)
+		}
+		assert.NoFileExists(t, path+".3")
+	})
+
+	t.Run("Without backups", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "h2h.log")
+		f, err := internal.OpenRotatingFile(path, 1, 0)
+		require.NoError(t, err)
+		for _, c := range []byte("ab") {
+			_, err := f.Write(chunk(c))
+			require.NoError(t, err)
+		}
+		require.NoError(t, f.Close())
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, chunk('b'), content)
+		assert.NoFileExists(t, path+".1")
+	})
+
+	t.Run("Without rotation", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "h2h.log")
+		f, err := internal.OpenRotatingFile(path, 0, 2)
+		require.NoError(t, err)
+		for _, c := range []byte("ab") {
+			_, err := f.Write(chunk(c))
+			require.NoError(t, err)
+		}
+		require.NoError(t, f.Close())
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, append(chunk('a'), chunk('b')...), content)
+		assert.NoFileExists(t, path+".1")
+	})
+}
+
+func TestConvertShowProgress(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "a.md", content: createTestContent("A", "2023-05-01", nil, nil, "This is post a.")},
+		{name: "b/b.md", content: createTestContent("B", "2023-05-02", nil, nil, "This is post b.")},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	cfg.ShowProgress = true
+	summary, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.SuccessCount)
+	verifyFileContent(t, dstDir, "b/b.md", "This is post b.")
+}
+
+func TestConvertChecksumFile(t *testing.T) {
+	srcDir, _ := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "b/post.md", content: createTestContent("Post", "2023-05-01", nil, nil, "This is a post.")},
+		{name: "a.md", content: createTestContent("A", "2023-05-02", nil, nil, "This is post a.")},
+	})
+
+	testCases := []struct {
+		name     string
+		sortKey  string
+		expected []string
+	}{
+		{
+			name:     "Destination paths",
+			expected: []string{"a.md", "b/post.md"},
+		},
+		{
+			name:     "Sorted paths",
+			sortKey:  "date",
+			expected: []string{"0002-a.md", "b/0001-post.md"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dstDir := filepath.Join(t.TempDir(), "dst")
+			checksumFile := filepath.Join(t.TempDir(), "SHA256SUMS")
+
+			cfg := internal.NewDefaultConfig()
+			cfg.ChecksumFile = checksumFile
+			cfg.PostSortKey = tc.sortKey
+			_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+			require.NoError(t, err)
+
+			var expected strings.Builder
+			for _, name := range tc.expected {
+				content, err := os.ReadFile(filepath.Join(dstDir, name))
+				require.NoError(t, err)
+				sum := sha256.Sum256(content)
+				expected.WriteString(hex.EncodeToString(sum[:]) + "  " + name + "\n")
+			}
+			data, err := os.ReadFile(checksumFile)
+			require.NoError(t, err)
+			assert.Equal(t, expected.String(), string(data))
+		})
+	}
+}
+
+func TestConvertReverseOrderProcessing(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "2023-01-01-first.md", content: createTestContent("First", "2023-01-01", nil, nil, "First post.")},
+		{name: "2023-02-01-second.md", content: createTestContent("Second", "2023-02-01", nil, nil, "Second post.")},
+		{name: "2023-03-01-third.md", content: createTestContent("Third", "2023-03-01", nil, nil, "Third post.")},
+	})
+
+	var started []string
+	cfg := internal.NewDefaultConfig()
+	cfg.ReverseOrderProcessing = true
+	cfg.OnFileStart = func(relPath string) {
+		started = append(started, relPath)
+	}
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2023-03-01-third.md", "2023-02-01-second.md", "2023-01-01-first.md"}, started)
+}
+
+func TestConvertTemporaryDirectory(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "post.md", content: createTestContent("Post", "2023-05-01", nil, nil, "This is a post.")},
+	})
+	tmpDir := t.TempDir()
+
+	var logs bytes.Buffer
+	cfg := internal.NewDefaultConfig()
+	cfg.TemporaryDirectory = tmpDir
+	cfg.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.NoError(t, err)
+	verifyFileContent(t, dstDir, "post.md", "This is a post.")
+	assert.NotContains(t, logs.String(), "another filesystem")
+
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "temporary files are renamed away")
+
+	cfg.TemporaryDirectory = filepath.Join(tmpDir, "missing")
+	_, err = internal.ConvertPosts(srcDir, dstDir, cfg)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestConvertEnforceUTF8(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "latin1.md", content: "---\ntitle: Caf\xe9\n---\nBody\n"},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	summary, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+	require.Error(t, err)
+	require.Len(t, summary.Errors, 1)
+	assert.ErrorContains(t, summary.Errors[0], "not valid UTF-8")
+	assert.NoFileExists(t, filepath.Join(dstDir, "latin1.md"))
+
+	cfg.EnforceUTF8 = false
+	summary, _ = internal.ConvertPosts(srcDir, dstDir, cfg)
+	for _, err := range summary.Errors {
+		assert.NotContains(t, err.Error(), "not valid UTF-8")
+	}
+}
+
+func TestConvertFileRetries(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "post.md", content: createTestContent("Post", "2023-05-01", nil, nil, "This is a post.")},
+	})
+	// a directory in place of the destination file makes every write fail
+	require.NoError(t, os.MkdirAll(filepath.Join(dstDir, "post.md", "blocker"), 0755))
+
+	testCases := []struct {
+		name    string
+		retries int
+		timeout time.Duration
+		logged  int
+	}{
+		{name: "No retries", retries: 0, logged: 0},
+		{name: "Retried", retries: 3, logged: 3},
+		{name: "Timed out", retries: 100, timeout: 50 * time.Millisecond, logged: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			cfg := internal.NewDefaultConfig()
+			cfg.FileRetries = tc.retries
+			cfg.RetryDelay = 10 * time.Millisecond
+			cfg.MaxRetryBackoff = 20 * time.Millisecond
+			cfg.FileTimeout = tc.timeout
+			cfg.Logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			_, err := internal.ConvertPosts(srcDir, dstDir, cfg)
+			require.Error(t, err)
+
+			retries := strings.Count(logs.String(), "retrying file operation")
+			if tc.timeout > 0 {
+				assert.Positive(t, retries)
+				assert.LessOrEqual(t, retries, tc.logged, "the timeout stops retrying")
+			} else {
+				assert.Equal(t, tc.logged, retries)
+			}
+		})
+	}
+}
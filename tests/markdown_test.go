@@ -0,0 +1,584 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pplmx/h2h/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func convertMarkdown(t *testing.T, cfg *internal.Config, content string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	err := internal.NewMarkdownConverter(cfg).ConvertMarkdown(strings.NewReader(content), &buf)
+	require.NoError(t, err)
+	return buf.String()
+}
+
+func TestConvertMarkdownReadingTime(t *testing.T) {
+	content := "---\ntitle: Reading\n---\n" + strings.Repeat("word ", 250)
+
+	testCases := []struct {
+		name     string
+		wpm      int
+		expected string
+	}{
+		{name: "Default speed", wpm: 200, expected: "reading_time: 2\n"},
+		{name: "Fast reader", wpm: 300, expected: "reading_time: 1\n"},
+		{name: "Slow reader", wpm: 100, expected: "reading_time: 3\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.GenerateReadingTime = true
+			cfg.ReadingSpeedWPM = tc.wpm
+			assert.Contains(t, convertMarkdown(t, cfg, content), tc.expected)
+		})
+	}
+}
+
+func TestConvertMarkdownWordCount(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.GenerateWordCount = true
+
+	output := convertMarkdown(t, cfg, "---\ntitle: Counting\n---\n# Heading\n\nOne two three.\n")
+	assert.Contains(t, output, "word_count: 5\n")
+}
+
+func TestConvertMarkdownSelfClosingHTMLTags(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.ConvertSelfClosingHTMLTags = true
+
+	output := convertMarkdown(t, cfg, "---\ntitle: Tags\n---\nLine<br/>break<BR />\n<hr/>\n<img src=\"a.png\" alt=\"a\" />\n<div/>\n")
+	assert.Contains(t, output, "Line<br>break<BR>\n<hr>\n<img src=\"a.png\" alt=\"a\">\n<div/>\n")
+}
+
+func TestConvertMarkdownKeepOriginalFrontMatter(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.KeepOriginalFrontMatter = true
+
+	output := convertMarkdown(t, cfg, "---\ntitle: Original\npermalink: original\n---\nBody")
+	assert.Equal(t, "---\nslug: original\ntitle: Original\n# ORIGINAL FRONT MATTER:\n# title: Original\n# permalink: original\n---\nBody", output)
+
+	cfg.FrontMatterCommentChar = "##"
+	output = convertMarkdown(t, cfg, "---\ntitle: Original\n---\nBody")
+	assert.Equal(t, "---\ntitle: Original\n## ORIGINAL FRONT MATTER:\n## title: Original\n---\nBody", output)
+
+	var logs bytes.Buffer
+	cfg.TargetFormat = "json"
+	cfg.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	output = convertMarkdown(t, cfg, "---\ntitle: Original\n---\nBody")
+	assert.NotContains(t, output, "ORIGINAL FRONT MATTER")
+	assert.Contains(t, logs.String(), "level=WARN")
+}
+
+func TestConvertMarkdownAutoDescription(t *testing.T) {
+	body := "# Heading\n\n```\ncode block\n```\n![cover](cover.png)\n\nThe **first** paragraph\nwith a [link](https://example.com) and <em>HTML</em>.\n\nSecond paragraph.\n"
+
+	testCases := []struct {
+		name        string
+		frontMatter string
+		maxLen      int
+		expected    string
+	}{
+		{name: "Extracted", frontMatter: "title: Post\n", expected: "The first paragraph with a link and HTML."},
+		{name: "Truncated", frontMatter: "title: Post\n", maxLen: 20, expected: "The first paragraph ..."},
+		{name: "Existing kept", frontMatter: "description: Mine\n", expected: "Mine"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.AutoDescription = true
+			if tc.maxLen > 0 {
+				cfg.DescriptionMaxLen = tc.maxLen
+			}
+			output := convertMarkdown(t, cfg, "---\n"+tc.frontMatter+"---\n"+body)
+
+			var frontMatter map[string]interface{}
+			parts := strings.SplitN(output, "---\n", 3)
+			require.Len(t, parts, 3)
+			require.NoError(t, yaml.Unmarshal([]byte(parts[1]), &frontMatter))
+			assert.Equal(t, tc.expected, frontMatter["description"])
+			assert.True(t, strings.HasSuffix(output, body))
+		})
+	}
+}
+
+func TestConvertMarkdownStripHTMLComments(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{name: "Inline", body: "Intro <!-- TODO: update this --> text.\n", expected: "Intro  text.\n"},
+		{name: "Multi-line", body: "Before\n<!--\n  editor note\n-->\nAfter\n", expected: "Before\n\nAfter\n"},
+		{name: "Nested-looking", body: "A <!-- outer <!-- inner --> tail -->\n", expected: "A  tail -->\n"},
+		{name: "More divider kept", body: "Summary\n<!--more-->\n<!-- hidden -->Rest\n", expected: "Summary\n<!--more-->\nRest\n"},
+		{name: "Spaced more divider kept", body: "Summary\n<!-- more -->\nRest\n", expected: "Summary\n<!-- more -->\nRest\n"},
+		{name: "Code blocks untouched", body: "```html\n<!-- sample -->\n```\n<!-- note -->\n", expected: "```html\n<!-- sample -->\n```\n\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.StripHTMLCommentsFromBody = true
+			assert.Equal(t, "---\ntitle: Comments\n---\n"+tc.expected, convertMarkdown(t, cfg, "---\ntitle: Comments\n---\n"+tc.body))
+		})
+	}
+}
+
+func TestConvertMarkdownFrontMatterFieldWidth(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.FrontMatterFieldWidth = 12
+	source := "---\ntitle: My Post\ndate: 2023-01-01\nmuch_too_long_key: yes\ntags:\n  - go\n\"a: b\": quoted\ndescription: |\n  Line one\n  title: not a key\n---\nBody\n"
+	expected := "---\n" +
+		"'a: b':      quoted\n" +
+		"date:        2023-01-01T00:00:00Z\n" +
+		"description: |\n" +
+		"    Line one\n" +
+		"    title: not a key\n" +
+		"much_too_long_key: \"yes\"\n" +
+		"tags:\n" +
+		"    - go\n" +
+		"title:       My Post\n" +
+		"---\nBody\n"
+	assert.Equal(t, expected, convertMarkdown(t, cfg, source))
+
+	cfg.TargetFormat = "toml"
+	assert.Contains(t, convertMarkdown(t, cfg, source), "title = \"My Post\"")
+}
+
+func TestConvertMarkdownTitleFromH1(t *testing.T) {
+	content := "---\ntitle: \"\"\ndate: 2023-05-01\n---\n```\n# not a heading\n```\n## Sub\n# The Real Title\nBody\n"
+
+	testCases := []struct {
+		name     string
+		remove   bool
+		expected string
+	}{
+		{name: "Heading kept", expected: "title: The Real Title\n---\n```\n# not a heading\n```\n## Sub\n# The Real Title\nBody\n"},
+		{name: "Heading removed", remove: true, expected: "title: The Real Title\n---\n```\n# not a heading\n```\n## Sub\nBody\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.TitleFromH1 = true
+			cfg.RemoveH1FromBody = tc.remove
+			assert.Contains(t, convertMarkdown(t, cfg, content), tc.expected)
+		})
+	}
+
+	cfg := internal.NewDefaultConfig()
+	cfg.TitleFromH1 = true
+	output := convertMarkdown(t, cfg, "---\ntitle: Existing\n---\n# Heading\n")
+	assert.Contains(t, output, "title: Existing\n")
+}
+
+func TestConvertMarkdownJSONFrontMatter(t *testing.T) {
+	yamlSource := "---\ntitle: JSON\nweight: 2\ntags:\n  - go\n  - hugo\n---\nBody with } braces {\n"
+	jsonSource := "{\n  \"title\": \"JSON\",\n  \"weight\": 2,\n  \"tags\": [\"go\", \"hugo\"]\n}\nBody with } braces {\n"
+
+	testCases := []struct {
+		name     string
+		source   string
+		from, to string
+		indent   int
+		expected string
+	}{
+		{
+			name:     "YAML to pretty JSON",
+			source:   yamlSource,
+			from:     "yaml",
+			to:       "json",
+			indent:   2,
+			expected: "{\n  \"tags\": [\n    \"go\",\n    \"hugo\"\n  ],\n  \"title\": \"JSON\",\n  \"weight\": 2\n}\nBody with } braces {\n",
+		},
+		{
+			name:     "YAML to compact JSON",
+			source:   yamlSource,
+			from:     "yaml",
+			to:       "json",
+			expected: "{\"tags\":[\"go\",\"hugo\"],\"title\":\"JSON\",\"weight\":2}\nBody with } braces {\n",
+		},
+		{
+			name:     "JSON to YAML",
+			source:   jsonSource,
+			from:     "json",
+			to:       "yaml",
+			expected: "---\ntags:\n    - go\n    - hugo\ntitle: JSON\nweight: 2\n---\nBody with } braces {\n",
+		},
+		{
+			name:     "Detected JSON to TOML",
+			source:   jsonSource,
+			from:     "detect",
+			to:       "toml",
+			expected: "---\ntags = [\"go\", \"hugo\"]\ntitle = \"JSON\"\nweight = 2\n---\nBody with } braces {\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.SourceFormat = tc.from
+			cfg.TargetFormat = tc.to
+			cfg.ForceTargetFormat = true
+			cfg.JSONIndent = tc.indent
+			assert.Equal(t, tc.expected, convertMarkdown(t, cfg, tc.source))
+		})
+	}
+}
+
+func TestConvertMarkdownIndent(t *testing.T) {
+	source := "---\ntitle: Indent\nparams:\n  author:\n    name: Jane\n  tags: [go, hugo]\n---\nBody\n"
+
+	testCases := []struct {
+		name     string
+		format   string
+		indent   int
+		expected string
+	}{
+		{
+			name:     "YAML 2",
+			format:   "yaml",
+			indent:   2,
+			expected: "---\nparams:\n  author:\n    name: Jane\n  tags:\n    - go\n    - hugo\ntitle: Indent\n---\nBody\n",
+		},
+		{
+			name:     "YAML 4",
+			format:   "yaml",
+			indent:   4,
+			expected: "---\nparams:\n    author:\n        name: Jane\n    tags:\n        - go\n        - hugo\ntitle: Indent\n---\nBody\n",
+		},
+		{
+			name:     "TOML 2",
+			format:   "toml",
+			indent:   2,
+			expected: "---\ntitle = \"Indent\"\n\n[params]\n  tags = [\"go\", \"hugo\"]\n  [params.author]\n    name = \"Jane\"\n---\nBody\n",
+		},
+		{
+			name:     "TOML 4",
+			format:   "toml",
+			indent:   4,
+			expected: "---\ntitle = \"Indent\"\n\n[params]\n    tags = [\"go\", \"hugo\"]\n    [params.author]\n        name = \"Jane\"\n---\nBody\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.TargetFormat = tc.format
+			cfg.YAMLIndent = tc.indent
+			cfg.TOMLIndent = tc.indent
+			assert.Equal(t, tc.expected, convertMarkdown(t, cfg, source))
+		})
+	}
+}
+
+func TestConvertMarkdownTOMLDelimiter(t *testing.T) {
+	source := "+++\ntitle = \"Pluses\"\nweight = 2\n+++\nBody\n---\nafter a rule\n"
+
+	testCases := []struct {
+		name      string
+		to        string
+		delimiter string
+		expected  string
+	}{
+		{
+			name:     "TOML keeps source delimiter",
+			to:       "toml",
+			expected: "+++\ntitle = \"Pluses\"\nweight = 2\n+++\nBody\n---\nafter a rule\n",
+		},
+		{
+			name:      "YAML with forced delimiter",
+			to:        "yaml",
+			delimiter: "---",
+			expected:  "---\ntitle: Pluses\nweight: 2\n---\nBody\n---\nafter a rule\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.SourceFormat = "toml"
+			cfg.TargetFormat = tc.to
+			cfg.OutputDelimiter = tc.delimiter
+			assert.Equal(t, tc.expected, convertMarkdown(t, cfg, source))
+		})
+	}
+}
+
+func TestConvertMarkdownNormalizeDates(t *testing.T) {
+	content := "---\ntitle: Dates\ndate: 2023-05-01 10:30:00\n---\nBody\n"
+
+	testCases := []struct {
+		name     string
+		format   string
+		layout   string
+		expected string
+	}{
+		{name: "TOML datetime", format: "toml", expected: "---\ndate = 2023-05-01T10:30:00Z\ntitle = \"Dates\"\n---\nBody\n"},
+		{name: "YAML timestamp", format: "yaml", expected: "---\ndate: 2023-05-01T10:30:00Z\ntitle: Dates\n---\nBody\n"},
+		{name: "Custom layout string", format: "toml", layout: "2006-01-02 15:04", expected: "---\ndate = \"2023-05-01 10:30\"\ntitle = \"Dates\"\n---\nBody\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.TargetFormat = tc.format
+			cfg.NormalizeDates = true
+			cfg.DateLayout = tc.layout
+			assert.Equal(t, tc.expected, convertMarkdown(t, cfg, content))
+		})
+	}
+}
+
+func TestSplitContent(t *testing.T) {
+	frontMatter, body, delimiter, err := internal.SplitContent("+++\ntitle = \"a---b\"\n+++\nBody\n")
+	require.NoError(t, err)
+	assert.Equal(t, "\ntitle = \"a---b\"\n", frontMatter)
+	assert.Equal(t, "\nBody\n", body)
+	assert.Equal(t, "+++", delimiter)
+
+	_, _, delimiter, err = internal.SplitContent("---\ntitle: a\n---\n")
+	require.NoError(t, err)
+	assert.Equal(t, "---", delimiter)
+}
+
+func TestSplitMarkdown(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		delimiter   string
+		frontMatter string
+		body        string
+		expectError bool
+	}{
+		{
+			name:        "Delimiters inside values and body",
+			content:     "---\ntitle: a---b\n---\nText\n\n---\n\n<!-- --- -->\n",
+			delimiter:   "---",
+			frontMatter: "\ntitle: a---b\n",
+			body:        "\nText\n\n---\n\n<!-- --- -->\n",
+		},
+		{
+			name:        "Leading blank lines and CRLF",
+			content:     "\r\n---\r\ntitle: a\r\n---\r\nText",
+			delimiter:   "---",
+			frontMatter: "\r\ntitle: a\r\n",
+			body:        "\r\nText",
+		},
+		{
+			name:        "Custom delimiter",
+			content:     ";;;\ntitle: a\n;;;\n---\n",
+			delimiter:   ";;;",
+			frontMatter: "\ntitle: a\n",
+			body:        "\n---\n",
+		},
+		{
+			name:        "Longer rule is not a delimiter",
+			content:     "---\ntitle: a\n----\n",
+			delimiter:   "---",
+			expectError: true,
+		},
+		{
+			name:        "No front matter before a rule",
+			content:     "# Title\n---\ntitle: a\n---\n",
+			delimiter:   "---",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			frontMatter, body, err := internal.SplitMarkdown([]byte(tc.content), tc.delimiter)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.frontMatter, string(frontMatter))
+			assert.Equal(t, tc.body, string(body))
+		})
+	}
+}
+
+func TestConvertMarkdownInputDelimiter(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.InputDelimiter = ";;;"
+	assert.Equal(t, "---\ntitle: Custom\n---\nBody\n", convertMarkdown(t, cfg, ";;;\ntitle: Custom\n;;;\nBody\n"))
+
+	cfg.OutputDelimiter = "+++"
+	cfg.TargetFormat = "toml"
+	assert.Equal(t, "+++\ntitle = \"Custom\"\n+++\nBody\n", convertMarkdown(t, cfg, ";;;\ntitle: Custom\n;;;\nBody\n"))
+}
+
+func TestConvertMarkdownCustomFrontMatterSeparator(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.CustomFrontMatterSeparator = "==="
+	cfg.InputDelimiter = ";;;"
+	cfg.OutputDelimiter = "+++"
+	assert.Equal(t, "===\ntitle: Custom\n===\nBody\n", convertMarkdown(t, cfg, "===\ntitle: Custom\n===\nBody\n"))
+
+	converted, err := internal.NewFrontMatterConverter(cfg).ConvertFrontMatter("title: Custom\n")
+	require.NoError(t, err)
+	assert.Equal(t, "===\ntitle: Custom\n===", converted)
+
+	var buf bytes.Buffer
+	err = internal.NewMarkdownConverter(cfg).ConvertMarkdown(strings.NewReader("===\ntitle: Custom\n---\nBody\n"), &buf)
+	assert.ErrorContains(t, err, "must appear at least twice")
+}
+
+func TestConvertMarkdownExportFrontMatterOnly(t *testing.T) {
+	source := "---\ntitle: Metadata\nupdated: 2023-05-02\n---\n# Heading\n\nA long body.\n"
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{format: "yaml", expected: "---\nlastmod: 2023-05-02T00:00:00Z\ntitle: Metadata\n---\n"},
+		{format: "json", expected: "{\"lastmod\":\"2023-05-02T00:00:00Z\",\"title\":\"Metadata\"}\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.TargetFormat = tc.format
+			cfg.JSONIndent = 0
+			cfg.ExportFrontMatterOnly = true
+			assert.Equal(t, tc.expected, convertMarkdown(t, cfg, source))
+		})
+	}
+}
+
+func TestConvertMarkdownLineBreakStrategy(t *testing.T) {
+	body := "First line\nsecond line\nthird line\n\n# Heading\n- item one\n- item two\n\n```\ncode one\ncode two\n```\nBreak  \nkept\n"
+
+	testCases := []struct {
+		strategy string
+		expected string
+	}{
+		{strategy: "none", expected: body},
+		{
+			strategy: "softbreak",
+			expected: "First line second line third line\n\n# Heading\n- item one\n- item two\n\n```\ncode one\ncode two\n```\nBreak  \nkept\n",
+		},
+		{
+			strategy: "hardbreak",
+			expected: "First line  \nsecond line  \nthird line\n\n# Heading\n- item one\n- item two\n\n```\ncode one\ncode two\n```\nBreak  \nkept\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.strategy, func(t *testing.T) {
+			cfg := internal.NewDefaultConfig()
+			cfg.LineBreakStrategy = tc.strategy
+			output := convertMarkdown(t, cfg, "---\ntitle: Breaks\n---\n"+body)
+			assert.Equal(t, "---\ntitle: Breaks\n---\n"+tc.expected, output)
+		})
+	}
+
+	cfg := internal.NewDefaultConfig()
+	cfg.LineBreakStrategy = "wrap"
+	err := internal.NewMarkdownConverter(cfg).ConvertMarkdown(strings.NewReader("---\ntitle: Breaks\n---\n"+body), io.Discard)
+	assert.ErrorContains(t, err, "unsupported line break strategy: wrap")
+}
+
+func TestConvertMarkdownCodeblockLanguages(t *testing.T) {
+	body := "```js\nconst a = 1\n```\n\n  ~~~py {linenos=true}\n  print(1)\n  ~~~\n\n```\nplain\n```\n"
+
+	cfg := internal.NewDefaultConfig()
+	cfg.ConvertCodeblockLanguages = true
+	output := convertMarkdown(t, cfg, "---\ntitle: Code\n---\n"+body)
+	assert.Equal(t, "---\ntitle: Code\n---\n```javascript\nconst a = 1\n```\n\n  ~~~python {linenos=true}\n  print(1)\n  ~~~\n\n```\nplain\n```\n", output)
+
+	cfg.ConvertCodeblockLanguages = false
+	assert.Equal(t, "---\ntitle: Code\n---\n"+body, convertMarkdown(t, cfg, "---\ntitle: Code\n---\n"+body))
+}
+
+func TestConvertMarkdownRelativeLinks(t *testing.T) {
+	body := "See [bar](../bar.md#usage), ![logo](images/logo.png \"Logo\") and [local](#top).\n" +
+		"Also [site](https://example.com/a.md) and [root](/about/).\n\n```\n[code](../bar.md)\n```\n"
+	source := "---\ntitle: Links\n---\n" + body
+	srcPath := filepath.Join("hexo", "source", "_posts", "foo.md")
+	dstPath := filepath.Join("hugo", "content", "posts", "2023", "foo.md")
+
+	cfg := internal.NewDefaultConfig()
+	cfg.ConvertRelativeLinks = true
+	var out bytes.Buffer
+	require.NoError(t, internal.NewMarkdownConverter(cfg).ConvertMarkdownWithPaths(srcPath, dstPath, strings.NewReader(source), &out))
+	assert.Equal(t, "---\ntitle: Links\n---\n"+
+		"See [bar](../../../../hexo/source/bar.md#usage), ![logo](../../../../hexo/source/_posts/images/logo.png \"Logo\") and [local](#top).\n"+
+		"Also [site](https://example.com/a.md) and [root](/about/).\n\n```\n[code](../bar.md)\n```\n", out.String())
+
+	out.Reset()
+	require.NoError(t, internal.NewMarkdownConverter(cfg).ConvertMarkdown(strings.NewReader(source), &out))
+	assert.Equal(t, source, out.String(), "links are kept without paths")
+}
+
+func TestConvertMarkdownContentFooter(t *testing.T) {
+	cfg := internal.NewDefaultConfig()
+	cfg.ContentFooter = "{{< related-posts >}}\n*{{ .title }}* ({{ .slug }})"
+	output := convertMarkdown(t, cfg, "---\ntitle: Footer\nslug: footer\n---\nThe body.")
+	assert.True(t, strings.HasSuffix(output, "---\nThe body.\n{{< related-posts >}}\n*Footer* (footer)\n"), output)
+
+	cfg = internal.NewDefaultConfig()
+	cfg.ContentFooter = "{{ .title"
+	mc := internal.NewMarkdownConverter(cfg)
+	err := mc.ConvertMarkdown(strings.NewReader("---\ntitle: Footer\n---\nThe body.\n"), io.Discard)
+	assert.ErrorContains(t, err, "parsing content footer")
+
+	var logs bytes.Buffer
+	cfg = internal.NewDefaultConfig()
+	cfg.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	cfg.ContentFooter = "by {{ .author }}"
+	output = convertMarkdown(t, cfg, "---\ntitle: Footer\n---\nThe body.")
+	assert.True(t, strings.HasSuffix(output, "---\nThe body."), output)
+	assert.NotContains(t, output, "<no value>")
+	assert.Contains(t, logs.String(), "leaving out content footer")
+	assert.Contains(t, logs.String(), `map has no entry for key`)
+
+	cfg.ContentFooter = "{{ with index . \"author\" }}by {{ . }}{{ end }}"
+	output = convertMarkdown(t, cfg, "---\ntitle: Footer\n---\nThe body.")
+	assert.True(t, strings.HasSuffix(output, "---\nThe body.\n"), output)
+	assert.NotContains(t, output, "<no value>")
+}
+
+func TestConvertMarkdownDigestFrontMatter(t *testing.T) {
+	const key = "000102030405060708090a0b0c0d0e0f"
+
+	cfg := internal.NewDefaultConfig()
+	cfg.DigestFrontMatter = true
+	cfg.DigestKey = key
+	cfg.GenerateWordCount = true
+	converted := convertMarkdown(t, cfg, "---\ntitle: Signed\nupdated: 2023-05-02\n---\nThree word body\n")
+
+	frontMatter, _, _, err := internal.SplitContent(converted)
+	require.NoError(t, err)
+	var parsed map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(frontMatter), &parsed))
+	require.Contains(t, parsed, "_digest")
+	assert.Equal(t, 3, parsed["word_count"], "fields added after conversion are signed too")
+
+	digest, err := internal.FrontMatterDigest(parsed, key)
+	require.NoError(t, err)
+	assert.Equal(t, parsed["_digest"], digest)
+
+	parsed["title"] = "Tampered"
+	tampered, err := internal.FrontMatterDigest(parsed, key)
+	require.NoError(t, err)
+	assert.NotEqual(t, parsed["_digest"], tampered)
+
+	cfg.DigestKey = "not hex"
+	err = internal.NewMarkdownConverter(cfg).ConvertMarkdown(strings.NewReader("---\ntitle: Bad key\n---\n"), &bytes.Buffer{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+//go:build integration
+
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/pplmx/h2h/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestRoundTrip converts Hexo posts to Hugo and back and compares the front
+// matter of every post with its source, field by field
+func TestRoundTrip(t *testing.T) {
+	// expectedDiffs lists, per post, the fields known to change on the way
+	// through Hugo
+	testCases := []struct {
+		name          string
+		content       string
+		expectedDiffs []string
+	}{
+		{
+			name:    "basic.md",
+			content: "---\ntitle: Basic Post\ndate: 2023-05-01\nupdated: 2023-05-02 10:30:00\ntags: [go, hugo]\ncategories: [tech]\n---\nBody\n",
+		},
+		{
+			name:    "mapped.md",
+			content: "---\ntitle: Mapped\npermalink: mapped-post\ndescription: A post.\nkeywords: [seo]\nauthor: Jane\npublished: false\nredirect_from: /old/\ntoc: true\n---\nBody\n",
+		},
+		{
+			name:    "nested/image.md",
+			content: "---\ntitle: Image\ncover: /img/cover.png\n---\nBody\n",
+			// Hugo keeps every image under images, which has no Hexo key
+			expectedDiffs: []string{"cover", "images"},
+		},
+	}
+
+	files := make([]struct{ name, content string }, len(testCases))
+	for i, tc := range testCases {
+		files[i] = struct{ name, content string }{tc.name, tc.content}
+	}
+	srcDir, hugoDir := createTestEnvironment(t, files)
+	hexoDir := t.TempDir()
+
+	cfg := internal.NewDefaultConfig()
+	cfg.ConversionDirection = "hexo2hugo"
+	_, err := internal.ConvertPosts(srcDir, hugoDir, cfg)
+	require.NoError(t, err)
+
+	cfg = internal.NewDefaultConfig()
+	cfg.ConversionDirection = "hugo2hexo"
+	_, err = internal.ConvertPosts(hugoDir, hexoDir, cfg)
+	require.NoError(t, err)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			original := readFrontMatter(t, filepath.Join(srcDir, tc.name))
+			final := readFrontMatter(t, filepath.Join(hexoDir, tc.name))
+
+			var diffs []string
+			for _, key := range unionKeys(original, final) {
+				if !reflect.DeepEqual(original[key], final[key]) {
+					diffs = append(diffs, key)
+				}
+			}
+			for _, key := range diffs {
+				if !slices.Contains(tc.expectedDiffs, key) {
+					t.Errorf("field %q changed: %#v became %#v", key, original[key], final[key])
+				}
+			}
+			for _, key := range tc.expectedDiffs {
+				assert.Contains(t, diffs, key, "expected field %q to change", key)
+			}
+		})
+	}
+}
+
+func readFrontMatter(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	frontMatter, _, _, err := internal.SplitContent(string(content))
+	require.NoError(t, err)
+
+	var m map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(frontMatter), &m))
+	return m
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []map[string]interface{}{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
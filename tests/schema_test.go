@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/pplmx/h2h/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSchema(t *testing.T) {
+	srcDir, _ := createTestEnvironment(t, []struct{ name, content string }{
+		{
+			name:    "first.md",
+			content: "---\ntitle: First\ndate: 2023-05-01\ntags: [go, hugo]\nweight: 1\n---\nFirst post",
+		},
+		{
+			name:    "second.md",
+			content: "---\ntitle: Second\ndraft: true\nweight: two\n---\nSecond post",
+		},
+	})
+
+	schema, err := internal.GenerateSchema(srcDir, internal.NewDefaultConfig())
+	require.NoError(t, err)
+
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"title", "weight"}, schema.Required)
+
+	require.Contains(t, schema.Properties, "date")
+	assert.Equal(t, "string", schema.Properties["date"].Type)
+	assert.Equal(t, "date-time", schema.Properties["date"].Format)
+	assert.Equal(t, "array", schema.Properties["tags"].Type)
+	assert.Equal(t, "boolean", schema.Properties["draft"].Type)
+	assert.Equal(t, []string{"integer", "string"}, schema.Properties["weight"].Type)
+	assert.Equal(t, []interface{}{"First", "Second"}, schema.Properties["title"].Examples)
+}
+
+func TestGenerateSchemaInvalidFile(t *testing.T) {
+	srcDir, _ := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "invalid.md", content: "# No front matter"},
+	})
+
+	_, err := internal.GenerateSchema(srcDir, internal.NewDefaultConfig())
+	assert.ErrorContains(t, err, "invalid.md")
+}
+
+func TestGenerateSchemaDelimiter(t *testing.T) {
+	srcDir, _ := createTestEnvironment(t, []struct{ name, content string }{
+		{name: "post.md", content: "===\ntitle: Custom\nweight: 1\n===\nBody"},
+	})
+
+	cfg := internal.NewDefaultConfig()
+	cfg.CustomFrontMatterSeparator = "==="
+	schema, err := internal.GenerateSchema(srcDir, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"title", "weight"}, schema.Required)
+
+	cfg = internal.NewDefaultConfig()
+	cfg.InputDelimiter = "==="
+	schema, err = internal.GenerateSchema(srcDir, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"title", "weight"}, schema.Required)
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pplmx/h2h/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchAndConvert(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, nil)
+
+	cfg := internal.NewDefaultConfig()
+	cfg.WatchDebounce = 20 * time.Millisecond
+	cfg.WatchDelete = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- internal.WatchAndConvert(srcDir, dstDir, cfg, ctx) }()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+
+	srcPath := filepath.Join(srcDir, "nested", "watched.md")
+	dstPath := filepath.Join(dstDir, "nested", "watched.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(srcPath), 0755))
+
+	// The watcher starts asynchronously, so keep touching the file until it
+	// has been picked up
+	content := createTestContent("Watched", "2023-05-01", nil, nil, "This is a watched post.")
+	require.Eventually(t, func() bool {
+		require.NoError(t, os.WriteFile(srcPath, []byte(content), 0644))
+		_, err := os.Stat(dstPath)
+		return err == nil
+	}, 5*time.Second, 100*time.Millisecond)
+	verifyFileContent(t, filepath.Join(dstDir, "nested"), "watched.md", "This is a watched post.")
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "notes.txt"), []byte("not markdown"), 0644))
+
+	require.NoError(t, os.Remove(srcPath))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(dstPath)
+		return os.IsNotExist(err)
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.NoFileExists(t, filepath.Join(dstDir, "notes.txt"))
+}
+
+func TestWatchAndConvertDebounceAndPatch(t *testing.T) {
+	srcDir, dstDir := createTestEnvironment(t, nil)
+	patchFile := filepath.Join(t.TempDir(), "patch.json")
+	require.NoError(t, os.WriteFile(patchFile, []byte(`[{"op": "add", "path": "/patched", "value": true}]`), 0644))
+
+	var conversions atomic.Int32
+	cfg := internal.NewDefaultConfig()
+	cfg.WatchDebounce = 200 * time.Millisecond
+	cfg.FrontMatterPatchFile = patchFile
+	cfg.OnFileStart = func(string) { conversions.Add(1) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- internal.WatchAndConvert(srcDir, dstDir, cfg, ctx) }()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+
+	// wait for the watcher to pick up a first file, writing it less often
+	// than the debounce period so that each write can be converted
+	content := createTestContent("Watched", "2023-05-01", nil, nil, "This is a watched post.")
+	require.Eventually(t, func() bool {
+		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "first.md"), []byte(content), 0644))
+		_, err := os.Stat(filepath.Join(dstDir, "first.md"))
+		return err == nil
+	}, 5*time.Second, 3*cfg.WatchDebounce)
+	verifyFileContent(t, dstDir, "first.md", "patched: true")
+
+	// a burst of writes within the debounce period converts the file once
+	time.Sleep(2 * cfg.WatchDebounce)
+	conversions.Store(0)
+	for range 20 {
+		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "burst.md"), []byte(content), 0644))
+		time.Sleep(time.Millisecond)
+	}
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(dstDir, "burst.md"))
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	time.Sleep(2 * cfg.WatchDebounce)
+	assert.Equal(t, int32(1), conversions.Load())
+}
+
+func TestWatchAndConvertRejectsSameDir(t *testing.T) {
+	dir := t.TempDir()
+	err := internal.WatchAndConvert(dir, dir, internal.NewDefaultConfig(), context.Background())
+	assert.Error(t, err)
+}